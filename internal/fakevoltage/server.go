@@ -0,0 +1,503 @@
+// Package fakevoltage implements an in-memory Voltage API, for tests to run the
+// provider against without a Voltage account.
+//
+// It supports creating, reading, listing, upgrading and deleting nodes,
+// updating their settings and whitelist, checking node names, reading node
+// logs, what clients need to connect to a node and the account the token
+// belongs to. New nodes start waiting_init and are running from the second
+// time they are listed, so that waiting for either status takes a couple of
+// status checks. Upgraded nodes go through starting the same way. Reading a
+// node doesn't change its status.
+//
+// Like Voltage, deleting a node only sets its status to deleted, it is still
+// read and listed afterwards. Requests for a node that doesn't exist fail with
+// a 400 and the message the API documents.
+package fakevoltage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+// authHeader is the header the provider sends the API token in by default.
+const authHeader = "X-VOLTAGE-AUTH"
+
+const (
+	// LndVersion is the LND version new nodes run.
+	LndVersion = "v0.16.4-beta"
+	// LatestLndVersion is the LND version upgraded nodes run.
+	LatestLndVersion = "v0.17.0-beta"
+)
+
+// invalidNodeID is the message Voltage answers with for unknown node IDs.
+const invalidNodeID = "node_id is invalid"
+
+// Server is a fake Voltage API. Point the provider host at its URL.
+type Server struct {
+	*httptest.Server
+
+	// Token is the API token requests must carry, any token is accepted if
+	// it is empty.
+	Token string
+
+	mu    sync.Mutex
+	nodes map[string]*voltage.NodeDocument
+	// listed are the IDs of the nodes listed at least once.
+	listed map[string]bool
+	nextID int
+	// calls counts the requests by path.
+	calls map[string]int
+}
+
+// NewServer starts a Server, callers must Close it.
+func NewServer() *Server {
+	s := &Server{
+		nodes:  make(map[string]*voltage.NodeDocument),
+		listed: make(map[string]bool),
+		calls:  make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/node", s.handleNode)
+	mux.HandleFunc("/node/create", s.handleCreate)
+	mux.HandleFunc("/node/delete", s.handleDelete)
+	mux.HandleFunc("/node/settings", s.handleSettings)
+	mux.HandleFunc("/node/connect", s.handleConnect)
+	mux.HandleFunc("/node/whitelist", s.handleWhitelist)
+	mux.HandleFunc("/node/update", s.handleUpdate)
+	mux.HandleFunc("/node/logs", s.handleLogs)
+	mux.HandleFunc("/node/name", s.handleName)
+	mux.HandleFunc("/user", s.handleUser)
+	s.Server = httptest.NewServer(s.authenticate(mux))
+
+	return s
+}
+
+// Node returns a copy of the node with the given ID, if it exists.
+func (s *Server) Node(nodeID string) (voltage.NodeDocument, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return voltage.NodeDocument{}, false
+	}
+
+	return *node, true
+}
+
+// UpdateNode applies update to the node with the given ID, as if it was
+// changed outside of the provider, and reports whether the node exists.
+func (s *Server) UpdateNode(nodeID string, update func(*voltage.NodeDocument)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[nodeID]
+	if ok {
+		update(node)
+	}
+
+	return ok
+}
+
+// Calls returns how many requests were made to path.
+func (s *Server) Calls(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[path]
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.calls[r.URL.Path]++
+		s.mu.Unlock()
+
+		if s.Token != "" && r.Header.Get(authHeader) != s.Token {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleNode lists the nodes on GET and reads one on POST.
+func (s *Server) handleNode(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		nodes := make([]*voltage.NodeDocument, 0, len(s.nodes))
+		for id, node := range s.nodes {
+			// Only move on once the initial status was seen, as a real
+			// node would stay in it for a while.
+			if s.listed[id] {
+				advance(node)
+			}
+			s.listed[id] = true
+			nodes = append(nodes, node)
+		}
+		writeJSON(w, map[string]any{"nodes": nodes})
+	case http.MethodPost:
+		var req voltage.NodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		node, ok := s.nodes[req.NodeId]
+		if !ok {
+			writeError(w, http.StatusBadRequest, invalidNodeID)
+
+			return
+		}
+		writeJSON(w, node)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+	}
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeCreateJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, node := range s.nodes {
+		if nameTaken(node, req.Name, req.Network) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("node name %s is taken", req.Name))
+
+			return
+		}
+	}
+
+	s.nextID++
+	id := fmt.Sprintf("node-%d", s.nextID)
+	created := time.Now().UTC().Format(time.RFC3339)
+	settings := req.Settings
+	s.nodes[id] = &voltage.NodeDocument{
+		NodeId:          &id,
+		NodeName:        &req.Name,
+		Network:         &req.Network,
+		PurchasedType:   &req.PurchasedType,
+		Type:            &req.Type,
+		Created:         &created,
+		Status:          toPtr("waiting_init"),
+		LndVersion:      toPtr(LndVersion),
+		UpdateAvailable: toPtr(true),
+		ApiEndpoint:     toPtr(req.Name + ".m.voltageapp.io"),
+		Settings:        &settings,
+	}
+
+	writeJSON(w, map[string]any{
+		"node_id":        id,
+		"created":        created,
+		"network":        req.Network,
+		"purchased_type": req.PurchasedType,
+		"type":           req.Type,
+	})
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	var req voltage.NodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[req.NodeId]
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidNodeID)
+
+		return
+	}
+	node.Status = toPtr("deleted")
+
+	writeJSON(w, node)
+}
+
+// handleSettings replaces the settings of a node. Like the real API, it
+// requires the settings that the spec marks as required, but tor which no
+// client can send as the spec doesn't describe it.
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeSettingsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	settings := req.Settings
+	var missing []string
+	for name, set := range map[string]bool{
+		"autopilot": settings.Autopilot != nil,
+		"alias":     settings.Alias != nil,
+		"color":     settings.Color != nil,
+		"grpc":      settings.Grpc != nil,
+		"rest":      settings.Rest != nil,
+		"keysend":   settings.Keysend != nil,
+		"whitelist": settings.Whitelist != nil,
+	} {
+		if !set {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		writeError(w, http.StatusBadRequest, "missing settings: "+strings.Join(missing, ", "))
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.liveNode(req.NodeId)
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidNodeID)
+
+		return
+	}
+	node.Settings = &settings
+
+	writeJSON(w, node)
+}
+
+// handleConnect returns the connection details of a node. Only the admin
+// macaroon is backed up, like for nodes initialized through Voltage.
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeConnectJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.liveNode(req.NodeId)
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidNodeID)
+
+		return
+	}
+
+	conn := map[string]any{
+		"endpoint": node.ApiEndpoint,
+		"tls_cert": "dGxzLWNlcnQ=",
+	}
+	if req.Name == "admin" {
+		conn["macaroon"] = "ZW5jcnlwdGVkLW1hY2Fyb29u"
+	}
+	writeJSON(w, conn)
+}
+
+// handleWhitelist replaces the whitelist of a node, without restarting it.
+func (s *Server) handleWhitelist(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeWhitelistJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	whitelist := make([]string, 0, len(req.Whitelist))
+	for _, ip := range req.Whitelist {
+		s, ok := ip.(string)
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("whitelist entry %v is not a string", ip))
+
+			return
+		}
+		whitelist = append(whitelist, s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.liveNode(req.NodeId)
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidNodeID)
+
+		return
+	}
+	if node.Settings == nil {
+		node.Settings = &voltage.NodeSettings{}
+	}
+	node.Settings.Whitelist = &whitelist
+
+	writeJSON(w, map[string]any{
+		"node_id":   req.NodeId,
+		"whitelist": whitelist,
+	})
+}
+
+// handleUpdate upgrades a node to LatestLndVersion. The node restarts, it is
+// starting until it has been listed once.
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeUpdateJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.liveNode(req.NodeId)
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidNodeID)
+
+		return
+	}
+	if node.UpdateAvailable == nil || !*node.UpdateAvailable {
+		writeError(w, http.StatusBadRequest, "node is already up to date")
+
+		return
+	}
+	node.LndVersion = toPtr(LatestLndVersion)
+	node.UpdateAvailable = toPtr(false)
+	node.Status = toPtr("starting")
+	delete(s.listed, req.NodeId)
+
+	writeJSON(w, map[string]any{
+		"node_id":      req.NodeId,
+		"lnd_version":  LatestLndVersion,
+		"volt_version": "v0.3.0",
+	})
+}
+
+// handleLogs returns a couple of made up LND log lines.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeLogsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.liveNode(req.NodeId)
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidNodeID)
+
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"node_id":       req.NodeId,
+		"node_name":     node.NodeName,
+		"last_modified": node.Created,
+		"log_lines": []string{
+			"2021-02-10 21:41:13.867 [INF] DISC: Broadcasting 3 new announcements in 1 sub batches",
+			"2021-02-10 21:41:18.935 [INF] CRTR: Processed channels=0 updates=3 nodes=0 in last 1m0.000018738s",
+		},
+	})
+}
+
+// handleName reports whether a node name is taken on a network.
+func (s *Server) handleName(w http.ResponseWriter, r *http.Request) {
+	var req voltage.PostNodeNameJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad request body")
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taken := false
+	for _, node := range s.nodes {
+		taken = taken || nameTaken(node, req.NodeName, req.Network)
+	}
+
+	writeJSON(w, map[string]any{
+		"node_name": req.NodeName,
+		"taken":     taken,
+		"valid":     req.NodeName != "",
+	})
+}
+
+// handleUser returns the account the token belongs to.
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+
+		return
+	}
+
+	writeJSON(w, voltage.UserDocument{
+		UserId:                 toPtr("user-1"),
+		Email:                  toPtr("user@example.com"),
+		EmailVerified:          toPtr(true),
+		AvailableStandardNodes: toPtr[float32](1),
+		AvailableLiteNodes:     toPtr[float32](1),
+	})
+}
+
+// liveNode returns the node with the given ID, unless it doesn't exist or was
+// deleted. The caller must hold s.mu.
+func (s *Server) liveNode(nodeID string) (*voltage.NodeDocument, bool) {
+	node, ok := s.nodes[nodeID]
+	if !ok || *node.Status == "deleted" {
+		return nil, false
+	}
+
+	return node, true
+}
+
+// nameTaken reports whether node uses name on network. Deleted nodes free
+// their name.
+func nameTaken(node *voltage.NodeDocument, name, network string) bool {
+	return *node.NodeName == name && *node.Network == network && *node.Status != "deleted"
+}
+
+// advance moves node to its next status, as if time passed.
+func advance(node *voltage.NodeDocument) {
+	switch *node.Status {
+	case "waiting_init", "starting":
+		node.Status = toPtr("running")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(voltage.N400{Message: &msg})
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
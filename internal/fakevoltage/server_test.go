@@ -0,0 +1,260 @@
+package fakevoltage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+func newTestClient(t *testing.T, s *Server, token string) *voltage.ClientWithResponses {
+	t.Helper()
+
+	c, err := voltage.NewClientWithResponses(s.URL, voltage.WithRequestEditorFn(
+		func(_ context.Context, req *http.Request) error {
+			req.Header.Set(authHeader, token)
+
+			return nil
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// listStatus lists the nodes and returns the status of nodeID, empty if it
+// isn't listed.
+func listStatus(t *testing.T, c *voltage.ClientWithResponses, nodeID string) string {
+	t.Helper()
+
+	resp, err := c.GetNodeWithResponse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Nodes == nil {
+		t.Fatalf("unexpected list response: %d %s", resp.StatusCode(), resp.Body)
+	}
+
+	for _, n := range *resp.JSON200.Nodes {
+		if n.NodeId != nil && *n.NodeId == nodeID {
+			return *n.Status
+		}
+	}
+
+	return ""
+}
+
+func TestServerNodeLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+	c := newTestClient(t, s, "")
+
+	created, err := c.PostNodeCreateWithResponse(ctx, voltage.PostNodeCreateJSONRequestBody{
+		Name:          "node",
+		Network:       "testnet",
+		PurchasedType: "paid",
+		Type:          "standard",
+		Settings:      voltage.NodeSettings{Alias: toPtr("alias")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.JSON200 == nil || created.JSON200.NodeId == nil {
+		t.Fatalf("unexpected create response: %d %s", created.StatusCode(), created.Body)
+	}
+	nodeID := *created.JSON200.NodeId
+
+	// Reads don't move the node along, a wait listing the nodes must see
+	// every status.
+	for i := 0; i < 2; i++ {
+		read, err := c.PostNodeWithResponse(ctx, voltage.NodeRequest{NodeId: nodeID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := *read.JSON200.Status; got != "waiting_init" {
+			t.Fatalf("read status = %q, want waiting_init", got)
+		}
+	}
+	for i, want := range []string{"waiting_init", "running", "running"} {
+		if got := listStatus(t, c, nodeID); got != want {
+			t.Fatalf("list %d status = %q, want %q", i, got, want)
+		}
+	}
+
+	node, ok := s.Node(nodeID)
+	if !ok {
+		t.Fatal("created node not found")
+	}
+	if *node.Settings.Alias != "alias" || *node.NodeName != "node" {
+		t.Errorf("node doesn't match the create request: %+v", node)
+	}
+
+	deleted, err := c.PostNodeDeleteWithResponse(ctx, voltage.PostNodeDeleteJSONRequestBody{NodeId: nodeID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.StatusCode() != http.StatusOK {
+		t.Fatalf("delete status code = %d", deleted.StatusCode())
+	}
+
+	// Deleted nodes are still read and listed, only their status changes.
+	read, err := c.PostNodeWithResponse(ctx, voltage.NodeRequest{NodeId: nodeID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.JSON200 == nil || *read.JSON200.Status != "deleted" {
+		t.Errorf("read of a deleted node = %d %s, want it with status deleted", read.StatusCode(), read.Body)
+	}
+	if got := listStatus(t, c, nodeID); got != "deleted" {
+		t.Errorf("deleted node is listed as %q, want deleted", got)
+	}
+
+	read, err = c.PostNodeWithResponse(ctx, voltage.NodeRequest{NodeId: "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.JSON400 == nil || *read.JSON400.Message != "node_id is invalid" {
+		t.Errorf("read of an unknown node = %d %s, want a 400 with node_id is invalid", read.StatusCode(), read.Body)
+	}
+}
+
+func TestServerRejectsDuplicateNames(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+	c := newTestClient(t, s, "")
+
+	body := voltage.PostNodeCreateJSONRequestBody{Name: "node", Network: "testnet", PurchasedType: "paid", Type: "standard"}
+	for i, want := range []int{http.StatusOK, http.StatusBadRequest} {
+		resp, err := c.PostNodeCreateWithResponse(ctx, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode() != want {
+			t.Errorf("create %d status code = %d, want %d", i, resp.StatusCode(), want)
+		}
+	}
+}
+
+func TestServerAuthentication(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Token = "secret"
+
+	for token, want := range map[string]int{
+		"secret": http.StatusOK,
+		"wrong":  http.StatusUnauthorized,
+	} {
+		resp, err := newTestClient(t, s, token).GetNodeWithResponse(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode() != want {
+			t.Errorf("token %q: status code = %d, want %d", token, resp.StatusCode(), want)
+		}
+	}
+}
+
+func TestServerNodeUpdates(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+	c := newTestClient(t, s, "")
+
+	created, err := c.PostNodeCreateWithResponse(ctx, voltage.PostNodeCreateJSONRequestBody{
+		Name: "node", Network: "testnet", PurchasedType: "paid", Type: "standard",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeID := *created.JSON200.NodeId
+
+	taken, err := c.PostNodeNameWithResponse(ctx, voltage.PostNodeNameJSONRequestBody{NodeName: "node", Network: "testnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken.JSON200 == nil || !*taken.JSON200.Taken {
+		t.Errorf("name of an existing node isn't taken: %d %s", taken.StatusCode(), taken.Body)
+	}
+
+	whitelist, err := c.PostNodeWhitelistWithResponse(ctx, voltage.PostNodeWhitelistJSONRequestBody{
+		NodeId:    nodeID,
+		Whitelist: []interface{}{"1.1.1.1", "2.2.2.2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if whitelist.StatusCode() != http.StatusOK {
+		t.Fatalf("whitelist status code = %d", whitelist.StatusCode())
+	}
+	if node, _ := s.Node(nodeID); len(*node.Settings.Whitelist) != 2 {
+		t.Errorf("whitelist = %v, want the 2 entries sent", *node.Settings.Whitelist)
+	}
+
+	// Upgrading restarts the node, and can only be done once.
+	listStatus(t, c, nodeID)
+	for i, want := range []int{http.StatusOK, http.StatusBadRequest} {
+		upgraded, err := c.PostNodeUpdateWithResponse(ctx, voltage.PostNodeUpdateJSONRequestBody{NodeId: nodeID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if upgraded.StatusCode() != want {
+			t.Errorf("upgrade %d status code = %d, want %d", i, upgraded.StatusCode(), want)
+		}
+	}
+	for i, want := range []string{"starting", "running"} {
+		if got := listStatus(t, c, nodeID); got != want {
+			t.Fatalf("list %d after the upgrade status = %q, want %q", i, got, want)
+		}
+	}
+	if node, _ := s.Node(nodeID); *node.LndVersion != LatestLndVersion {
+		t.Errorf("lnd_version after the upgrade = %s, want %s", *node.LndVersion, LatestLndVersion)
+	}
+
+	logs, err := c.PostNodeLogsWithResponse(ctx, voltage.NodeRequest{NodeId: nodeID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs.JSON200 == nil || len(*logs.JSON200.LogLines) == 0 {
+		t.Errorf("unexpected logs response: %d %s", logs.StatusCode(), logs.Body)
+	}
+
+	// A deleted node frees its name and can't be changed anymore.
+	if _, err := c.PostNodeDeleteWithResponse(ctx, voltage.PostNodeDeleteJSONRequestBody{NodeId: nodeID}); err != nil {
+		t.Fatal(err)
+	}
+	taken, err = c.PostNodeNameWithResponse(ctx, voltage.PostNodeNameJSONRequestBody{NodeName: "node", Network: "testnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken.JSON200 == nil || *taken.JSON200.Taken {
+		t.Errorf("name of a deleted node is taken: %d %s", taken.StatusCode(), taken.Body)
+	}
+	whitelist, err = c.PostNodeWhitelistWithResponse(ctx, voltage.PostNodeWhitelistJSONRequestBody{
+		NodeId:    nodeID,
+		Whitelist: []interface{}{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if whitelist.JSON400 == nil || *whitelist.JSON400.Message != "node_id is invalid" {
+		t.Errorf("whitelist of a deleted node = %d %s, want a 400 with node_id is invalid", whitelist.StatusCode(), whitelist.Body)
+	}
+}
+
+func TestServerUser(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	resp, err := newTestClient(t, s, "").GetUserWithResponse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.JSON200 == nil || resp.JSON200.UserId == nil {
+		t.Errorf("unexpected user response: %d %s", resp.StatusCode(), resp.Body)
+	}
+}
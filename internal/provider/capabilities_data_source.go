@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var capabilitiesDataSourceSchema = schema.Schema{
+	Description: "Lists the values the provider accepts when creating a node",
+	Attributes: map[string]schema.Attribute{
+		"networks": schema.ListAttribute{
+			Description: "Networks a node can run on",
+			Computed:    true,
+			ElementType: types.StringType,
+		},
+		"node_types": schema.ListAttribute{
+			Description: "Types of node",
+			Computed:    true,
+			ElementType: types.StringType,
+		},
+		"purchased_types": schema.ListAttribute{
+			Description: "Purchase types of a node",
+			Computed:    true,
+			ElementType: types.StringType,
+		},
+	},
+}
+
+type capabilitiesDataSourceModel struct {
+	Networks       []types.String `tfsdk:"networks"`
+	NodeTypes      []types.String `tfsdk:"node_types"`
+	PurchasedTypes []types.String `tfsdk:"purchased_types"`
+}
+
+// CapabilitiesDataSource exposes the provider's known values, the Voltage API
+// doesn't list them, so it makes no API calls.
+type CapabilitiesDataSource struct{}
+
+func NewCapabilitiesDataSource() datasource.DataSource {
+	return &CapabilitiesDataSource{}
+}
+
+func (d *CapabilitiesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_capabilities"
+}
+
+func (d *CapabilitiesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = capabilitiesDataSourceSchema
+}
+
+func (d *CapabilitiesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	state := capabilitiesDataSourceModel{
+		Networks:       each(nodeNetworks, types.StringValue),
+		NodeTypes:      each(nodeTypes, types.StringValue),
+		PurchasedTypes: each(nodePurchasedTypes, types.StringValue),
+	}
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
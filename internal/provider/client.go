@@ -1,166 +1,1291 @@
 package provider
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
 )
 
+const (
+	// defaultPollInterval is how long to wait before the first node status
+	// check.
+	defaultPollInterval = 1 * time.Second
+	// defaultMaxPollInterval is how long the wait between node status checks
+	// can grow.
+	defaultMaxPollInterval = 5 * time.Second
+	// defaultCreateTimeout bounds how long CreateNode waits for a node to
+	// initialize.
+	defaultCreateTimeout = 30 * time.Minute
+	// defaultUpgradeTimeout bounds how long UpgradeNode waits for a node to
+	// run again.
+	defaultUpgradeTimeout = 30 * time.Minute
+	// defaultRequestTimeout bounds each API call.
+	defaultRequestTimeout = 30 * time.Second
+)
+
+// ClientConfig tunes how the Client talks to the API. Zero values fall back
+// to the defaults.
+//
+// The timing knobs nest, each layer running inside the previous one:
+//   - CreateTimeout and UpgradeTimeout bound a whole wait, any call still in
+//     progress when they expire is cancelled.
+//   - The wait checks the node status first after PollInterval, then less
+//     and less often up to every MaxPollInterval, at most
+//     MaxPollAttempts times. A check that fails transiently, e.g. because its
+//     request timed out, is retried by the next check instead of aborting
+//     the wait, waiting at most RetryMaxBackoff before it. The wait gives up
+//     when checks keep failing for RetryMaxElapsed.
+//   - RequestTimeout bounds each API call, the lowest of it and what is left
+//     of the wait applies. It includes the MaxRetries retries of the call. Waiting for a free slot under
+//     MaxConcurrentRequests doesn't count against it.
+type ClientConfig struct {
+	// MaxConcurrentRequests limits the API calls in flight at once across
+	// all the resources sharing the Client, zero means unlimited.
+	MaxConcurrentRequests int64
+	// PollInterval is how long to wait before the first node status check,
+	// the wait grows by half after every check.
+	PollInterval time.Duration
+	// MaxPollInterval caps the wait between node status checks. It is
+	// raised to PollInterval if lower.
+	MaxPollInterval time.Duration
+	// MaxPollAttempts limits the status checks of a single wait, zero means
+	// the wait is only bounded by time.
+	MaxPollAttempts int
+	// RetryMaxBackoff caps the wait before retrying a failed status check,
+	// zero means it grows like the wait between checks.
+	RetryMaxBackoff time.Duration
+	// RetryMaxElapsed bounds how long status checks can keep failing before
+	// giving up, zero means only the wait timeout bounds them.
+	RetryMaxElapsed time.Duration
+	// MaxRetries is how many times an API call that failed transiently, with
+	// no response or a 429 or 5xx status, is sent again. The waits between
+	// them grow like the ones between status checks, up to RetryMaxBackoff.
+	// Zero means calls aren't retried. Node creations never are, as a
+	// failed one may still have created the node.
+	MaxRetries int
+	// CreateTimeout bounds how long CreateNode waits for a node to
+	// initialize.
+	CreateTimeout time.Duration
+	// UpgradeTimeout bounds how long UpgradeNode waits for a node to run
+	// again.
+	UpgradeTimeout time.Duration
+	// RequestTimeout bounds each API call, so that a stuck call fails without
+	// consuming the whole timeout of the operation making it.
+	RequestTimeout time.Duration
+	// AuthHeader is the header carrying the API token, used by the clients
+	// overriding the token. It defaults to X-VOLTAGE-AUTH.
+	AuthHeader string
+	// AuthScheme prefixes the token in AuthHeader if not empty.
+	AuthScheme string
+}
+
 type Client struct {
 	voltage *voltage.ClientWithResponses
+	// sem limits the number of in-flight API calls, nil means unlimited.
+	sem chan struct{}
+
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	maxPollAttempts int
+	retryMaxBackoff time.Duration
+	retryMaxElapsed time.Duration
+	maxRetries      int
+	createTimeout   time.Duration
+	upgradeTimeout  time.Duration
+	requestTimeout  time.Duration
+	authHeader      string
+	authScheme      string
+
+	// statuses caches the status of every node, so that concurrent waits
+	// share a single list call instead of each reading its own node.
+	statusesMu      sync.Mutex
+	statuses        map[string]listedNode
+	statusesFetched time.Time
+
+	// derived are the clients made by derive, by overrides.
+	derivedMu sync.Mutex
+	derived   map[clientOverrides]*Client
+}
+
+// NewClient wraps v using cfg.
+func NewClient(v *voltage.ClientWithResponses, cfg ClientConfig) *Client {
+	c := &Client{
+		voltage:         v,
+		pollInterval:    defaultPollInterval,
+		maxPollInterval: defaultMaxPollInterval,
+		createTimeout:   defaultCreateTimeout,
+		upgradeTimeout:  defaultUpgradeTimeout,
+		requestTimeout:  defaultRequestTimeout,
+		maxPollAttempts: cfg.MaxPollAttempts,
+		retryMaxBackoff: cfg.RetryMaxBackoff,
+		retryMaxElapsed: cfg.RetryMaxElapsed,
+		maxRetries:      cfg.MaxRetries,
+		authHeader:      authHeader,
+		authScheme:      cfg.AuthScheme,
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		c.sem = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+	if cfg.PollInterval > 0 {
+		c.pollInterval = cfg.PollInterval
+	}
+	if cfg.MaxPollInterval > 0 {
+		c.maxPollInterval = cfg.MaxPollInterval
+	}
+	if c.maxPollInterval < c.pollInterval {
+		c.maxPollInterval = c.pollInterval
+	}
+	if cfg.CreateTimeout > 0 {
+		c.createTimeout = cfg.CreateTimeout
+	}
+	if cfg.UpgradeTimeout > 0 {
+		c.upgradeTimeout = cfg.UpgradeTimeout
+	}
+	if cfg.RequestTimeout > 0 {
+		c.requestTimeout = cfg.RequestTimeout
+	}
+	if cfg.AuthHeader != "" {
+		c.authHeader = cfg.AuthHeader
+	}
+	if base, ok := v.ClientInterface.(*voltage.Client); ok {
+		c.voltage = c.retrying(base, base.RequestEditors)
+	}
+
+	return c
+}
+
+// clientOverrides are the Client settings a single resource can change,
+// zero values keep the ones of the provider.
+type clientOverrides struct {
+	token           string
+	pollInterval    time.Duration
+	maxPollAttempts int
+	maxRetries      int
+}
+
+// derive returns a Client with o applied over the settings of c, or c itself
+// if o overrides nothing. It shares the concurrency limit of c and is reused
+// for the same overrides, so that its waits share the status cache.
+func (c *Client) derive(o clientOverrides) (*Client, error) {
+	if o == (clientOverrides{}) {
+		return c, nil
+	}
+
+	c.derivedMu.Lock()
+	defer c.derivedMu.Unlock()
+
+	if dc, ok := c.derived[o]; ok {
+		return dc, nil
+	}
+
+	dc := &Client{
+		voltage:         c.voltage,
+		sem:             c.sem,
+		pollInterval:    c.pollInterval,
+		maxPollInterval: c.maxPollInterval,
+		maxPollAttempts: c.maxPollAttempts,
+		retryMaxBackoff: c.retryMaxBackoff,
+		retryMaxElapsed: c.retryMaxElapsed,
+		maxRetries:      c.maxRetries,
+		createTimeout:   c.createTimeout,
+		upgradeTimeout:  c.upgradeTimeout,
+		requestTimeout:  c.requestTimeout,
+		authHeader:      c.authHeader,
+		authScheme:      c.authScheme,
+	}
+	if o.pollInterval > 0 {
+		dc.pollInterval = o.pollInterval
+		if dc.maxPollInterval < dc.pollInterval {
+			dc.maxPollInterval = dc.pollInterval
+		}
+	}
+	if o.maxPollAttempts > 0 {
+		dc.maxPollAttempts = o.maxPollAttempts
+	}
+	if o.maxRetries > 0 {
+		dc.maxRetries = o.maxRetries
+	}
+
+	base, ok := c.voltage.ClientInterface.(*voltage.Client)
+	if !ok && o.token != "" {
+		return nil, fmt.Errorf("can't override the token of a %T", c.voltage.ClientInterface)
+	}
+	if ok {
+		editors := base.RequestEditors
+		if o.token != "" {
+			// Editors run in order, so this one replaces the provider token.
+			editors = append(editors[:len(editors):len(editors)],
+				func(_ context.Context, req *http.Request) error {
+					req.Header.Set(dc.authHeader, authValue(dc.authScheme, o.token))
+
+					return nil
+				},
+			)
+		}
+		// Retry with the settings of dc.
+		dc.voltage = dc.retrying(base, editors)
+	}
+
+	if c.derived == nil {
+		c.derived = make(map[clientOverrides]*Client)
+	}
+	c.derived[o] = dc
+
+	return dc, nil
+}
+
+// retrying returns a copy of base using editors, that retries its requests
+// as configured in c.
+func (c *Client) retrying(base *voltage.Client, editors []voltage.RequestEditorFn) *voltage.ClientWithResponses {
+	next := base.Client
+	if d, ok := next.(*retryingDoer); ok {
+		next = d.next
+	}
+
+	return &voltage.ClientWithResponses{ClientInterface: &voltage.Client{
+		Server:         base.Server,
+		Client:         &retryingDoer{next: next, c: c},
+		RequestEditors: editors,
+	}}
+}
+
+// noRetriesKey marks the context of requests that must not be retried.
+type noRetriesKey struct{}
+
+// withoutRetries returns ctx for a request that must be sent only once.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+// retryingDoer sends requests through next, and sends them again up to
+// c.maxRetries times while they fail transiently.
+type retryingDoer struct {
+	next voltage.HttpRequestDoer
+	c    *Client
 }
 
-func NewClient(v *voltage.ClientWithResponses) *Client {
-	return &Client{voltage: v}
+func (d *retryingDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	retries := d.c.maxRetries
+	// Requests whose body can't be read again can't be retried.
+	if ctx.Value(noRetriesKey{}) != nil || (req.Body != nil && req.GetBody == nil) {
+		retries = 0
+	}
+
+	backoff := d.c.pollInterval
+	for attempt := 1; ; attempt++ {
+		resp, err := d.next.Do(req)
+		if attempt > retries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		fields := map[string]any{
+			"method":  req.Method,
+			"path":    req.URL.Path,
+			"attempt": attempt,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			fields["status"] = resp.StatusCode
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			return resp, nil
+		}
+		tflog.Debug(ctx, "Retrying API call", fields)
+
+		wait := backoff
+		if d.c.retryMaxBackoff > 0 && wait > d.c.retryMaxBackoff {
+			wait = d.c.retryMaxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: ctx.Err()}
+		case <-time.After(wait):
+		}
+		backoff = d.c.nextPollInterval(backoff)
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// acquire blocks until an API call can be made without exceeding the
+// concurrency limit. The call must be made with the returned context, which
+// is bound by the request timeout, and the returned func must be called once
+// it is done.
+func (c *Client) acquire(ctx context.Context) (context.Context, func(), error) {
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	return callCtx, func() {
+		cancel()
+		if c.sem != nil {
+			<-c.sem
+		}
+	}, nil
 }
 
 type ClientError struct {
 	op  string
 	err error
+	// statusCode is the HTTP status returned by the API, zero if the request
+	// didn't get a response.
+	statusCode int
+	// method and path identify the failed request, they are empty if the
+	// error happened before sending it.
+	method string
+	path   string
+	// message is the one the API gave in the error response body, if any.
+	message string
 }
 
 func newClientError(op string, err error) *ClientError {
-	return &ClientError{op: op, err: err}
+	// The generated client fails to decode JSON bodies it can't parse,
+	// including empty ones, before assertBody gets to check them.
+	var sErr *json.SyntaxError
+	var tErr *json.UnmarshalTypeError
+	if errors.As(err, &sErr) || errors.As(err, &tErr) {
+		err = fmt.Errorf("malformed body: %w: %s", ErrInvalidAPIResponseBody, err)
+	}
+
+	cErr := &ClientError{op: op, err: err}
+
+	// Errors returned by the http.Client carry the request.
+	var uErr *url.Error
+	if errors.As(err, &uErr) {
+		cErr.method = strings.ToUpper(uErr.Op)
+		if u, err := url.Parse(uErr.URL); err == nil {
+			cErr.path = u.Path
+		}
+	}
+
+	return cErr
+}
+
+// newResponseError returns a ClientError for the request that got r.
+func newResponseError(r *http.Response, err error) *ClientError {
+	op := fmt.Sprintf("calling %s %s", r.Request.Method, r.Request.URL.Path)
+
+	cErr := newClientError(op, err)
+	cErr.method = r.Request.Method
+	cErr.path = r.Request.URL.Path
+
+	return cErr
 }
 
 func (e *ClientError) Error() string {
 	return fmt.Sprintf("%s: %s", e.op, e.err.Error())
 }
 
+func (e *ClientError) Unwrap() error {
+	return e.err
+}
+
+// isAuthError reports whether the API rejected the credentials.
+func isAuthError(e *ClientError) bool {
+	return e.statusCode == http.StatusUnauthorized || e.statusCode == http.StatusForbidden
+}
+
+// isTransient reports whether a failed API call is worth retrying: requests
+// that never got a response, including the ones that timed out, and 429 or
+// 5xx responses are, any other error (e.g. a 401) is not.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		return false
+	}
+
+	s := cErr.statusCode
+	return s == 0 || s == http.StatusTooManyRequests || s >= http.StatusInternalServerError
+}
+
 var (
 	ErrInvalidAPIResponseBody = errors.New("invalid API response body")
+	ErrNodeIDRequired         = errors.New("node_id is required")
+	ErrNodeNotFound           = errors.New("node not found")
 )
 
+// assertOK fails unless the API answered with a 2xx status code.
 func (c *Client) assertOK(r *http.Response, body []byte) error {
-	ok := http.StatusOK
 	s := r.StatusCode
-	if s == ok {
+	if s >= 200 && s < 300 {
 		return nil
 	}
 
-	op := fmt.Sprintf("calling %s %s", r.Request.Method, r.Request.URL.Path)
-	err := fmt.Errorf("Wanted StatusCode=%d, got %d (%s)", ok, s, string(body))
-
-	return newClientError(op, err)
-}
-
-func (c *Client) CreateNode(ctx context.Context, m *nodeModel) error {
-	body := voltage.PostNodeCreateJSONRequestBody{
-		Name:          m.Name.ValueString(),
-		Network:       m.Network.ValueString(),
-		PurchasedType: m.PurchasedType.ValueString(),
-		Type:          m.Type.ValueString(),
-		Settings: voltage.NodeSettings{
-			Autopilot: m.Settings.AutoPilot.ValueBoolPointer(),
-			Grpc:      m.Settings.Grpc.ValueBoolPointer(),
-			Rest:      m.Settings.Rest.ValueBoolPointer(),
-			Keysend:   m.Settings.Keysend.ValueBoolPointer(),
-			Whitelist: toPtr(each(
-				m.Settings.Whitelist, func(w types.String) string { return w.ValueString() },
-			)),
-			Alias:                          m.Settings.Alias.ValueStringPointer(),
-			Color:                          m.Settings.Color.ValueStringPointer(),
-			Wumbo:                          m.Settings.Wumbo.ValueBoolPointer(),
-			Webhook:                        m.Settings.Webhook.ValueStringPointer(),
-			WebhookSecret:                  m.Settings.WebhookSecret.ValueStringPointer(),
-			Minchansize:                    m.Settings.MinChanSize.ValueStringPointer(),
-			Maxchansize:                    m.Settings.MaxChanSize.ValueStringPointer(),
-			Autocompaction:                 m.Settings.AutoCompactation.ValueBoolPointer(),
-			Defaultfeerate:                 m.Settings.DefaultFeeRate.ValueStringPointer(),
-			Basefee:                        m.Settings.BaseFee.ValueStringPointer(),
-			Amp:                            m.Settings.Amp.ValueBoolPointer(),
-			Wtclient:                       m.Settings.WtClient.ValueBoolPointer(),
-			Maxpendingchannels:             m.Settings.MaxChanSize.ValueStringPointer(),
-			Allowcircularroute:             m.Settings.AllowCircularRoute.ValueBoolPointer(),
-			Numgraphsyncpeers:              m.Settings.NumGraphSyncPeers.ValueStringPointer(),
-			Gccanceledinvoicesonstartup:    m.Settings.GCCanceledInvoicesOnStartUp.ValueBoolPointer(),
-			Gccanceledinvoicesonthefly:     m.Settings.GCCanceledInvoicesOnTheFly.ValueBoolPointer(),
-			Torskipproxyforclearnettargets: m.Settings.TorSkipProxyForClearnetTargets.ValueBoolPointer(),
-			Rpcmiddleware:                  m.Settings.RPCMiddleware.ValueBoolPointer(),
-			Optionscidalias:                m.Settings.OptionSCIDAlias.ValueBoolPointer(),
-			Zeroconf:                       m.Settings.ZeroConf.ValueBoolPointer(),
-		},
-	}
-
-	tflog.Info(ctx, "Creating Node", map[string]any{"body": body})
-	resp, err := c.voltage.PostNodeCreateWithResponse(ctx, body)
-	if err != nil {
-		return newClientError("creating node", err)
+	err := fmt.Errorf("Wanted a 2xx StatusCode, got %d (%s)", s, string(body))
+
+	cErr := newResponseError(r, err)
+	cErr.statusCode = s
+
+	var apiErr voltage.N400
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != nil {
+		cErr.message = *apiErr.Message
 	}
 
-	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+	return cErr
+}
+
+// asNodeNotFound makes err, returned by assertOK, match ErrNodeNotFound if
+// the API answered that the node doesn't exist.
+func asNodeNotFound(err error, body []byte) error {
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
 		return err
 	}
 
+	// The API answers 400 with one of these messages for unknown node IDs.
+	msg := strings.ToLower(string(body))
+	switch {
+	case cErr.statusCode == http.StatusNotFound,
+		cErr.statusCode == http.StatusBadRequest &&
+			(strings.Contains(msg, "node_id is invalid") || strings.Contains(msg, "node_id is not valid")):
+		cErr.err = fmt.Errorf("%w: %s", ErrNodeNotFound, cErr.err.Error())
+	}
+
+	return err
+}
+
+// assertBody fails if the API answered successfully but the response body
+// couldn't be decoded, so that callers can safely dereference *v. The
+// generated client only decodes 200 responses, other 2xx ones are decoded
+// here.
+func assertBody[T any](r *http.Response, body []byte, v **T) error {
+	if *v != nil {
+		return nil
+	}
+
+	if r.StatusCode != http.StatusOK && len(body) > 0 {
+		var decoded T
+		if err := json.Unmarshal(body, &decoded); err == nil {
+			*v = &decoded
+			return nil
+		}
+	}
+
+	return newResponseError(r, fmt.Errorf("empty or malformed body: %w", ErrInvalidAPIResponseBody))
+}
+
+// CreateNode asks Voltage to create the node described by body and returns
+// its ID. The node is still provisioning when it returns, see
+// WaitNodeCreated.
+// The extra fields are added to the request body, unless body already sets
+// them.
+func (c *Client) CreateNode(ctx context.Context, body voltage.PostNodeCreateJSONRequestBody, extra map[string]json.RawMessage) (string, error) {
+	// Don't log the whole body, settings may contain secrets.
+	tflog.Info(ctx, "Creating Node", map[string]any{
+		"name":           body.Name,
+		"network":        body.Network,
+		"type":           body.Type,
+		"purchased_type": body.PurchasedType,
+	})
+	b, err := mergeCreateBody(body, extra)
+	if err != nil {
+		return "", newClientError("creating node", err)
+	}
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return "", newClientError("creating node", err)
+	}
+	resp, err := c.voltage.PostNodeCreateWithBodyWithResponse(withoutRetries(callCtx), "application/json", bytes.NewReader(b))
+	release()
+	if err != nil {
+		return "", newClientError("creating node", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return "", err
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return "", err
+	}
+
 	if resp.JSON200.NodeId == nil {
-		return fmt.Errorf("field `node_id` can't be nil: %w", ErrInvalidAPIResponseBody)
+		// The node may have been created anyway, look for it rather than
+		// leaving it orphaned.
+		tflog.Warn(ctx, "Node created without an ID, looking it up")
+		node, err := c.FindExistingNode(ctx, body.Name, body.Network)
+		if err != nil {
+			return "", fmt.Errorf("looking up node created without an ID: %w", err)
+		}
+		if node == nil || node.NodeId == nil || isTerminal(node.Status) {
+			return "", fmt.Errorf("field `node_id` can't be nil: %w", ErrInvalidAPIResponseBody)
+		}
+		resp.JSON200.NodeId = node.NodeId
 	}
 	nodeID := *resp.JSON200.NodeId
+	tflog.Info(ctx, "Node Created", map[string]any{"node_id": nodeID})
+
+	return nodeID, nil
+}
+
+// mergeCreateBody returns body encoded as JSON with the extra fields it
+// doesn't set.
+func mergeCreateBody(body voltage.PostNodeCreateJSONRequestBody, extra map[string]json.RawMessage) ([]byte, error) {
+	b, err := json.Marshal(body)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		if _, ok := fields[k]; ok {
+			continue
+		}
+		if !json.Valid(v) {
+			return nil, fmt.Errorf("extra field %s isn't valid JSON: %s", k, v)
+		}
+		fields[k] = v
+	}
+
+	return json.Marshal(fields)
+}
+
+// isCreateBodyField reports whether name is a field of the node creation
+// request body, which extra fields can't override.
+func isCreateBodyField(name string) bool {
+	b, _ := json.Marshal(voltage.PostNodeCreateJSONRequestBody{})
 
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(b, &fields)
+	_, ok := fields[name]
+
+	return ok
+}
+
+// WaitNodeCreated waits for the just created node to reach status and
+// returns it. An empty status returns the node right away.
+func (c *Client) WaitNodeCreated(ctx context.Context, nodeID, status string) (*voltage.NodeDocument, error) {
 	ctx = tflog.SetField(ctx, "node_id", nodeID)
-	tflog.Info(ctx, "Node Created, waiting initialization")
+	if status == "" {
+		tflog.Info(ctx, "Not waiting for the node to be ready")
+
+		return c.ReadNode(ctx, nodeID)
+	}
+
+	tflog.Info(ctx, "Waiting for the node status", map[string]any{
+		"want": status,
+	})
 
 	// Wait for the desired state.
-	var nodeStatus string
-	for nodeStatus != "waiting_init" {
+	waitCtx, cancel := context.WithTimeout(ctx, c.createTimeout)
+	defer cancel()
+
+	start := time.Now()
+	node, err := c.waitForStatus(waitCtx, nodeID, status)
+	if err != nil {
+		return nil, err
+	}
+	tflog.Info(ctx, "Node reached the wanted status!", map[string]any{
+		"elapsed": time.Since(start).Round(time.Second).String(),
+	})
+
+	// TODO: upload seed.
+	return node, nil
+}
+
+// WaitNodeStatus waits up to timeout for the node to reach status and
+// returns it. A zero timeout uses the create timeout.
+func (c *Client) WaitNodeStatus(ctx context.Context, nodeID, status string, timeout time.Duration) (*voltage.NodeDocument, error) {
+	if nodeID == "" {
+		return nil, newClientError("waiting for node", ErrNodeIDRequired)
+	}
+	if timeout <= 0 {
+		timeout = c.createTimeout
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return c.waitForStatus(waitCtx, nodeID, status)
+}
+
+// terminalNodeStatuses are the statuses a node never leaves, waiting on a node
+// in one of them can only time out. Voltage reports deleted nodes for a while
+// after deleting them.
+var terminalNodeStatuses = []string{"deleted"}
+
+// isTerminal reports whether status is one of terminalNodeStatuses. Nodes in
+// those are kept around by Voltage but can't be used anymore.
+func isTerminal(status *string) bool {
+	return status != nil && contains(terminalNodeStatuses, *status)
+}
+
+// jitter returns d randomly shifted by up to ±20%, averaging d.
+func jitter(rnd *rand.Rand, d time.Duration) time.Duration {
+	spread := int64(d) / 5
+	if spread <= 0 {
+		return d
+	}
+
+	return d - time.Duration(spread) + time.Duration(rnd.Int63n(2*spread+1))
+}
+
+// nextPollInterval returns the wait before the status check following one
+// made after interval. Nodes that aren't ready early tend to take long, so
+// they are checked less and less often, up to every c.maxPollInterval.
+func (c *Client) nextPollInterval(interval time.Duration) time.Duration {
+	if interval += interval / 2; interval > c.maxPollInterval {
+		return c.maxPollInterval
+	}
+
+	return interval
+}
+
+// waitForStatus polls the node until it reaches any of the wanted statuses
+// and returns it, see waitFor.
+func (c *Client) waitForStatus(ctx context.Context, nodeID string, want ...string) (*voltage.NodeDocument, error) {
+	op := fmt.Sprintf("waiting for node to be %s", strings.Join(want, " or "))
+
+	return c.waitFor(ctx, nodeID, op, func(n listedNode) bool {
+		return contains(want, n.status)
+	})
+}
+
+// waitFor polls the node, first after c.pollInterval and then less and less
+// often up to every c.maxPollInterval, until done accepts it as listed and
+// returns it. Transient API failures, including reading the node once it got
+// there, are logged and retried until they have lasted c.retryMaxElapsed, so
+// callers must bound the wait through ctx.
+func (c *Client) waitFor(ctx context.Context, nodeID, op string, done func(listedNode) bool) (*voltage.NodeDocument, error) {
+	ctx = tflog.SetField(ctx, "node_id", nodeID)
+
+	// Concurrent waits would poll in lockstep, spread them out.
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	interval := c.pollInterval
+
+	// Summarize the API usage to help tuning the poll interval and timeouts.
+	var (
+		attempts, retries int
+		waited            time.Duration
+		lastStatus        string
+		// failures counts the consecutive failed checks, since failingSince.
+		failures     int
+		failingSince time.Time
+	)
+	start := time.Now()
+	defer func() {
+		tflog.Debug(ctx, "Finished waiting for node status", map[string]any{
+			"attempts": attempts,
+			"retries":  retries,
+			"waited":   waited.Round(time.Millisecond).String(),
+			"elapsed":  time.Since(start).Round(time.Second).String(),
+		})
+	}()
+
+	// retry records a transient failure, and fails if they have lasted too
+	// long.
+	retry := func(err error) error {
+		retries++
+		if failures++; failures == 1 {
+			failingSince = time.Now()
+		}
+
+		elapsed := time.Since(failingSince)
+		if c.retryMaxElapsed > 0 && elapsed >= c.retryMaxElapsed {
+			return newClientError(op, fmt.Errorf("gave up after %d attempts over %s: %w",
+				failures, elapsed.Round(time.Second), err))
+		}
+
+		return nil
+	}
+
+	for {
+		if c.maxPollAttempts > 0 && attempts >= c.maxPollAttempts {
+			return nil, newClientError(op, fmt.Errorf("gave up after %d status checks, last status was %q",
+				attempts, lastStatus))
+		}
+
 		// Do not kill the API.
-		time.Sleep(3 * time.Second)
+		d := jitter(rnd, interval)
+		if failures > 0 && c.retryMaxBackoff > 0 && d > c.retryMaxBackoff {
+			d = c.retryMaxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return nil, newClientError(op, ctx.Err())
+		case <-time.After(d):
+			waited += d
+		}
+
+		interval = c.nextPollInterval(interval)
+
+		attempts++
+		listed, err := c.listedNode(ctx, nodeID)
+		if err != nil {
+			if !isTransient(err) {
+				return nil, err
+			}
+
+			// The node is still changing, a failed status check shouldn't
+			// abort the whole operation.
+			tflog.Warn(ctx, "Retrying node status check", map[string]any{"error": err.Error()})
+			if err := retry(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		failures = 0
 
-		node, err := c.voltage.PostNodeWithResponse(ctx, voltage.PostNodeJSONRequestBody{
-			NodeId: nodeID,
+		lastStatus = listed.status
+		tflog.Info(ctx, "Waiting for node status", map[string]any{
+			"status":  listed.status,
+			"op":      op,
+			"elapsed": time.Since(start).Round(time.Second).String(),
 		})
+
+		if contains(terminalNodeStatuses, listed.status) {
+			return nil, newClientError(op, fmt.Errorf("node status is %q, it won't change anymore", listed.status))
+		}
+		if !done(listed) {
+			continue
+		}
+
+		node, err := c.ReadNode(ctx, nodeID)
+		if err != nil && isTransient(err) {
+			tflog.Warn(ctx, "Retrying node read", map[string]any{"error": err.Error()})
+			if err := retry(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return node, err
+	}
+}
+
+// listedNode is what waits look at of a node, as listed.
+type listedNode struct {
+	status     string
+	lndVersion string
+}
+
+// listedNode returns the node as listed, with an empty status if it isn't
+// listed yet. Nodes are listed at most twice per poll interval however many
+// waits are in progress. Failures aren't cached, as they may be due to the
+// ctx of the wait that listed.
+func (c *Client) listedNode(ctx context.Context, nodeID string) (listedNode, error) {
+	c.statusesMu.Lock()
+	defer c.statusesMu.Unlock()
+
+	if time.Since(c.statusesFetched) >= c.pollInterval/2 {
+		nodes, err := c.ListNodes(ctx)
 		if err != nil {
-			return newClientError("retrieving node", err)
+			return listedNode{}, err
 		}
 
-		if err := c.assertOK(node.HTTPResponse, node.Body); err != nil {
-			return err
+		c.statuses, c.statusesFetched = make(map[string]listedNode, len(nodes)), time.Now()
+		for _, n := range nodes {
+			if n.NodeId == nil || n.Status == nil {
+				continue
+			}
+			listed := listedNode{status: *n.Status}
+			if n.LndVersion != nil {
+				listed.lndVersion = *n.LndVersion
+			}
+			c.statuses[*n.NodeId] = listed
 		}
+	}
+
+	return c.statuses[nodeID], nil
+}
+
+// incompleteReadRetries is how many times ReadNode reads a node again when
+// Voltage answers without its ID or status, which usually show up shortly.
+const incompleteReadRetries = 3
+
+func (c *Client) ReadNode(ctx context.Context, nodeID string) (*voltage.NodeDocument, error) {
+	if nodeID == "" {
+		return nil, newClientError("retrieving node", ErrNodeIDRequired)
+	}
 
-		if node.JSON200.Status == nil {
-			return fmt.Errorf("field node_id can't be nil: %w", ErrInvalidAPIResponseBody)
+	for retries := 0; ; retries++ {
+		// Malformed bodies fail right away, they won't get any better.
+		node, err := c.readNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		if node.NodeId != nil && node.Status != nil {
+			return node, nil
+		}
+
+		if retries == incompleteReadRetries {
+			return nil, newClientError("retrieving node",
+				fmt.Errorf("fields `node_id` and `status` can't be nil: %w", ErrInvalidAPIResponseBody))
+		}
+		tflog.Debug(ctx, "Incomplete node read, retrying", map[string]any{"node_id": nodeID})
+
+		select {
+		case <-ctx.Done():
+			return nil, newClientError("retrieving node", ctx.Err())
+		case <-time.After(c.pollInterval):
 		}
+	}
+}
+
+func (c *Client) readNode(ctx context.Context, nodeID string) (*voltage.NodeDocument, error) {
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, newClientError("retrieving node", err)
+	}
+	resp, err := c.voltage.PostNodeWithResponse(callCtx, voltage.NodeRequest{
+		NodeId: nodeID,
+	})
+	release()
+	if err != nil {
+		return nil, newClientError("retrieving node", err)
+	}
 
-		nodeStatus = *node.JSON200.Status
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return nil, asNodeNotFound(err, resp.Body)
 	}
-	tflog.Info(ctx, "Node initialized correctly!")
 
-	if resp.JSON200.Created == nil {
-		return fmt.Errorf("field `created` can't be nil: %w", ErrInvalidAPIResponseBody)
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return nil, err
 	}
-	created := *resp.JSON200.Created
 
-	m.NodeID = types.StringValue(nodeID)
-	m.Created = types.StringValue(created)
+	return resp.JSON200, nil
+}
 
-	// TODO: upload seed.
-	return nil
+// ListNodes returns every node in the account. The list endpoint doesn't
+// include the node settings, use ReadNode to get them.
+func (c *Client) ListNodes(ctx context.Context) ([]voltage.NodeDocument, error) {
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, newClientError("listing nodes", err)
+	}
+	resp, err := c.voltage.GetNodeWithResponse(callCtx)
+	release()
+	if err != nil {
+		return nil, newClientError("listing nodes", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return nil, err
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return nil, err
+	}
+
+	if resp.JSON200.Nodes == nil {
+		return nil, nil
+	}
+
+	nodes := make([]voltage.NodeDocument, 0, len(*resp.JSON200.Nodes))
+	for _, n := range *resp.JSON200.Nodes {
+		nodes = append(nodes, voltage.NodeDocument{
+			ApiEndpoint:     n.ApiEndpoint,
+			Created:         n.Created,
+			Expires:         n.Expires,
+			LndVersion:      n.LndVersion,
+			Network:         n.Network,
+			NodeId:          n.NodeId,
+			NodeName:        n.NodeName,
+			PurchaseStatus:  n.PurchaseStatus,
+			PurchasedType:   n.PurchasedType,
+			Status:          n.Status,
+			Type:            n.Type,
+			UpdateAvailable: n.UpdateAvailable,
+			VoltVersion:     n.VoltVersion,
+		})
+	}
+
+	return nodes, nil
+}
+
+// FindNodeByName returns the node called name. It fails if there is not
+// exactly one node with that name in the account.
+func (c *Client) FindNodeByName(ctx context.Context, name string) (*voltage.NodeDocument, error) {
+	nodes, err := c.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, n := range nodes {
+		if n.NodeName != nil && *n.NodeName == name && n.NodeId != nil && !isTerminal(n.Status) {
+			ids = append(ids, *n.NodeId)
+		}
+	}
+
+	op := fmt.Sprintf("looking up node %q", name)
+	switch len(ids) {
+	case 0:
+		return nil, newClientError(op, errors.New("no node found with that name"))
+	case 1:
+		return c.ReadNode(ctx, ids[0])
+	default:
+		return nil, newClientError(op, fmt.Errorf("found %d nodes with that name", len(ids)))
+	}
+}
+
+// NodeNameTaken reports whether name is already used by a node on network.
+func (c *Client) NodeNameTaken(ctx context.Context, name, network string) (bool, error) {
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return false, newClientError("checking node name", err)
+	}
+	resp, err := c.voltage.PostNodeNameWithResponse(callCtx, voltage.PostNodeNameJSONRequestBody{
+		NodeName: name,
+		Network:  network,
+	})
+	release()
+	if err != nil {
+		return false, newClientError("checking node name", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return false, err
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return false, err
+	}
+
+	return resp.JSON200.Taken != nil && *resp.JSON200.Taken, nil
+}
+
+// UpdateSettings replaces the settings of the node. The API requires all of
+// them, including the ones that can't change once the node exists, so
+// settings must be the full settings of the node.
+func (c *Client) UpdateSettings(ctx context.Context, nodeID string, settings voltage.NodeSettings) error {
+	ctx = tflog.SetField(ctx, "node_id", nodeID)
+	// Don't log the settings, they may contain secrets.
+	tflog.Info(ctx, "Updating node settings")
+
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return newClientError("updating node settings", err)
+	}
+	resp, err := c.voltage.PostNodeSettingsWithResponse(callCtx, voltage.PostNodeSettingsJSONRequestBody{
+		NodeId:   nodeID,
+		Settings: settings,
+	})
+	release()
+	if err != nil {
+		return newClientError("updating node settings", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return err
+	}
+
+	return assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200)
 }
 
-func (c *Client) ReadNode(ctx context.Context, nodeID string) error {
-	resp, err := c.voltage.PostNodeWithResponse(ctx, voltage.NodeRequest{
+// FindExistingNode returns the node called name running on network, or nil if
+// there's none. It fails if more than one node matches.
+func (c *Client) FindExistingNode(ctx context.Context, name, network string) (*voltage.NodeDocument, error) {
+	nodes, err := c.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, n := range nodes {
+		if n.NodeName != nil && *n.NodeName == name &&
+			n.Network != nil && *n.Network == network && n.NodeId != nil && !isTerminal(n.Status) {
+			ids = append(ids, *n.NodeId)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return nil, nil
+	case 1:
+		return c.ReadNode(ctx, ids[0])
+	default:
+		op := fmt.Sprintf("looking up node %q on %s", name, network)
+		return nil, newClientError(op, fmt.Errorf("found %d nodes with that name", len(ids)))
+	}
+}
+
+// UpgradeNode upgrades the node to the latest LND version offered by Voltage
+// and waits until it is running again. It returns the new LND version.
+func (c *Client) UpgradeNode(ctx context.Context, nodeID string) (string, error) {
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return "", newClientError("upgrading node", err)
+	}
+	resp, err := c.voltage.PostNodeUpdateWithResponse(callCtx, voltage.PostNodeUpdateJSONRequestBody{
 		NodeId: nodeID,
 	})
+	release()
+	if err != nil {
+		return "", newClientError("upgrading node", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return "", err
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return "", err
+	}
+
+	if resp.JSON200.LndVersion == nil {
+		return "", fmt.Errorf("field `lnd_version` can't be nil: %w", ErrInvalidAPIResponseBody)
+	}
+
+	version := *resp.JSON200.LndVersion
+	ctx = tflog.SetField(ctx, "node_id", nodeID)
+	tflog.Info(ctx, "Node upgrade started, waiting for it to run", map[string]any{
+		"lnd_version": version,
+	})
+
+	waitCtx, cancel := context.WithTimeout(ctx, c.upgradeTimeout)
+	defer cancel()
+
+	// The node keeps running the old version until the upgrade starts,
+	// waiting for running right away would return before it did.
+	_, err = c.waitFor(waitCtx, nodeID, "waiting for node upgrade to start", func(n listedNode) bool {
+		return (n.status != "" && n.status != "running") ||
+			(n.lndVersion != "" && compareVersions(n.lndVersion, version) == 0)
+	})
 	if err != nil {
-		return newClientError("retrieving node", err)
+		return "", err
+	}
+	if _, err := c.waitForStatus(waitCtx, nodeID, "running"); err != nil {
+		return "", err
+	}
+	tflog.Info(ctx, "Node upgraded correctly!")
+
+	return version, nil
+}
+
+// UpdateWhitelist replaces the list of IPs allowed to talk to the node.
+func (c *Client) UpdateWhitelist(ctx context.Context, nodeID string, ips []string) error {
+	body := voltage.PostNodeWhitelistJSONRequestBody{
+		NodeId:    nodeID,
+		Whitelist: make([]interface{}, 0, len(ips)),
+	}
+	for _, ip := range ips {
+		body.Whitelist = append(body.Whitelist, ip)
+	}
+
+	ctx = tflog.SetField(ctx, "node_id", nodeID)
+	tflog.Info(ctx, "Updating node whitelist", map[string]any{"whitelist": ips})
+
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return newClientError("updating whitelist", err)
+	}
+	resp, err := c.voltage.PostNodeWhitelistWithResponse(callCtx, body)
+	release()
+	if err != nil {
+		return newClientError("updating whitelist", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		if resp.JSON400 != nil && resp.JSON400.Message != nil {
+			if entry, ok := findWhitelistEntry(*resp.JSON400.Message, ips); ok {
+				return &WhitelistEntryError{Entry: entry, err: err}
+			}
+		}
+
+		return err
 	}
 
-	return c.assertOK(resp.HTTPResponse, resp.Body)
+	return assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200)
+}
+
+// WhitelistEntryError is returned when the API rejects a whitelist because of
+// one of its entries.
+type WhitelistEntryError struct {
+	Entry string
+	err   error
+}
+
+func (e *WhitelistEntryError) Error() string {
+	return fmt.Sprintf("whitelist entry %q was rejected: %s", e.Entry, e.err.Error())
+}
+
+func (e *WhitelistEntryError) Unwrap() error {
+	return e.err
+}
+
+// findWhitelistEntry returns the entry of ips that msg refers to, if any.
+func findWhitelistEntry(msg string, ips []string) (string, bool) {
+	words := strings.FieldsFunc(msg, func(r rune) bool {
+		return !strings.ContainsRune("0123456789abcdefABCDEF.:/", r)
+	})
+	for _, w := range words {
+		w = normalizeIP(strings.Trim(w, ".:"))
+		if contains(ips, w) {
+			return w, true
+		}
+	}
+
+	return "", false
 }
 
 func (c *Client) DeleteNode(ctx context.Context, nodeID string) error {
-	resp, err := c.voltage.PostNodeDeleteWithResponse(ctx, voltage.PostNodeDeleteJSONRequestBody{
+	if nodeID == "" {
+		return newClientError("deleting node", ErrNodeIDRequired)
+	}
+
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return newClientError("deleting node", err)
+	}
+	resp, err := c.voltage.PostNodeDeleteWithResponse(callCtx, voltage.PostNodeDeleteJSONRequestBody{
 		NodeId: nodeID,
 	})
+	release()
 	if err != nil {
 		return newClientError("deleting node", err)
 	}
 
-	return c.assertOK(resp.HTTPResponse, resp.Body)
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return asNodeNotFound(err, resp.Body)
+	}
+
+	return assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200)
+}
+
+// NodeLogs returns the last LND log lines of the node, oldest first, and
+// when the most recent one was written. Voltage keeps at most 100 lines.
+func (c *Client) NodeLogs(ctx context.Context, nodeID string) ([]string, string, error) {
+	if nodeID == "" {
+		return nil, "", newClientError("retrieving node logs", ErrNodeIDRequired)
+	}
+
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, "", newClientError("retrieving node logs", err)
+	}
+	resp, err := c.voltage.PostNodeLogsWithResponse(callCtx, voltage.NodeRequest{
+		NodeId: nodeID,
+	})
+	release()
+	if err != nil {
+		return nil, "", newClientError("retrieving node logs", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return nil, "", asNodeNotFound(err, resp.Body)
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return nil, "", err
+	}
+
+	var lines []string
+	if resp.JSON200.LogLines != nil {
+		lines = *resp.JSON200.LogLines
+	}
+	var lastModified string
+	if resp.JSON200.LastModified != nil {
+		lastModified = *resp.JSON200.LastModified
+	}
+
+	return lines, lastModified, nil
+}
+
+// NodeConnection is what clients need to connect to a node.
+type NodeConnection struct {
+	Endpoint string
+	// TLSCert is base64 encoded.
+	TLSCert string
+	// Macaroon is encrypted with the password of the account, it is empty
+	// if no macaroon with the requested name was backed up.
+	Macaroon string
+}
+
+// NodeConnection returns the connection details of the node, with the backed
+// up macaroon called macaroonName.
+func (c *Client) NodeConnection(ctx context.Context, nodeID, macaroonName string) (*NodeConnection, error) {
+	if nodeID == "" {
+		return nil, newClientError("retrieving node connection", ErrNodeIDRequired)
+	}
+
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, newClientError("retrieving node connection", err)
+	}
+	resp, err := c.voltage.PostNodeConnectWithResponse(callCtx, voltage.PostNodeConnectJSONRequestBody{
+		NodeId: nodeID,
+		Name:   macaroonName,
+	})
+	release()
+	if err != nil {
+		return nil, newClientError("retrieving node connection", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return nil, asNodeNotFound(err, resp.Body)
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return nil, err
+	}
+
+	conn := &NodeConnection{}
+	if resp.JSON200.Endpoint != nil {
+		conn.Endpoint = *resp.JSON200.Endpoint
+	}
+	if resp.JSON200.TlsCert != nil {
+		conn.TLSCert = *resp.JSON200.TlsCert
+	}
+	if resp.JSON200.Macaroon != nil {
+		conn.Macaroon = *resp.JSON200.Macaroon
+	}
+
+	return conn, nil
+}
+
+// GetUser returns the account the token belongs to. It is the cheapest
+// authenticated call in the API.
+func (c *Client) GetUser(ctx context.Context) (*voltage.UserDocument, error) {
+	callCtx, release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, newClientError("retrieving user", err)
+	}
+	resp, err := c.voltage.GetUserWithResponse(callCtx)
+	release()
+	if err != nil {
+		return nil, newClientError("retrieving user", err)
+	}
+
+	if err := c.assertOK(resp.HTTPResponse, resp.Body); err != nil {
+		return nil, err
+	}
+
+	if err := assertBody(resp.HTTPResponse, resp.Body, &resp.JSON200); err != nil {
+		return nil, err
+	}
+
+	return resp.JSON200, nil
 }
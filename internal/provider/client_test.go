@@ -0,0 +1,1204 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/qustavo/terraform-provider-voltage/internal/fakevoltage"
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+// newTestClient returns a Client, configured with cfg, for an API served by
+// handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc, cfg ClientConfig) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	v, err := voltage.NewClientWithResponses(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewClient(v, cfg)
+}
+
+func TestClientUpdateSettingsSendsFullSettings(t *testing.T) {
+	var body map[string]json.RawMessage
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/node/settings" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("invalid body %s: %v", b, err)
+		}
+		writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "restarting"}`)
+	}, ClientConfig{})
+
+	settings := nodeSettingsModel{}
+	settings.setFromAPI(&voltage.NodeSettings{
+		Autopilot: toPtr(false),
+		Grpc:      toPtr(true),
+		Rest:      toPtr(false),
+		Keysend:   toPtr(true),
+		Whitelist: &[]string{"1.2.3.4"},
+		Alias:     toPtr("alias"),
+		Color:     toPtr("#ffffff"),
+		Wumbo:     toPtr(true),
+	})
+	apiSettings, err := settings.toAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.UpdateSettings(context.Background(), "node-1", apiSettings); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(body["node_id"]); got != `"node-1"` {
+		t.Errorf("node_id = %s, want \"node-1\"", got)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body["settings"], &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"autopilot": false,
+		"grpc":      true,
+		"rest":      false,
+		"keysend":   true,
+		"whitelist": []any{"1.2.3.4"},
+		"alias":     "alias",
+		"color":     "#ffffff",
+		"wumbo":     true,
+	}
+	for k, v := range want {
+		if b, _ := json.Marshal(got[k]); string(b) != mustJSON(t, v) {
+			t.Errorf("settings.%s = %s, want %s", k, b, mustJSON(t, v))
+		}
+	}
+}
+
+func TestClientWaitStopsOnDeletedNode(t *testing.T) {
+	var lists int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "deleted"}]}`)
+	}, ClientConfig{PollInterval: time.Millisecond})
+
+	_, err := c.WaitNodeStatus(context.Background(), "node-1", "running", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), `"deleted"`) {
+		t.Fatalf("err = %v, want the node to be reported as deleted", err)
+	}
+	if lists != 1 {
+		t.Errorf("listed nodes %d times, want 1", lists)
+	}
+}
+
+func TestClientWaitRetriesFailedStatusCheck(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status  int
+		wantErr bool
+	}{
+		"transient": {status: http.StatusServiceUnavailable},
+		"fatal":     {status: http.StatusUnauthorized, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var lists int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+
+					return
+				}
+				if lists++; lists == 1 {
+					writeJSON(w, tc.status, `{"message": "failed"}`)
+
+					return
+				}
+				writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "running"}]}`)
+			}, ClientConfig{PollInterval: time.Millisecond})
+
+			node, err := c.WaitNodeStatus(context.Background(), "node-1", "running", time.Minute)
+			if tc.wantErr {
+				if err == nil || lists != 1 {
+					t.Errorf("err = %v after %d status checks, want to fail on the first one", err, lists)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lists != 2 || node.Status == nil || *node.Status != "running" {
+				t.Errorf("got status %v after %d status checks, want running after 2", node.Status, lists)
+			}
+		})
+	}
+}
+
+func TestClientWaitLogsRetries(t *testing.T) {
+	var lists int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+
+			return
+		}
+		switch lists++; lists {
+		case 1:
+			writeJSON(w, http.StatusServiceUnavailable, `{"message": "failed"}`)
+		case 2:
+			writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "waiting_init"}]}`)
+		default:
+			writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "running"}]}`)
+		}
+	}, ClientConfig{PollInterval: time.Millisecond})
+
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+	if _, err := c.WaitNodeStatus(ctx, "node-1", "running", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary map[string]any
+	for _, e := range entries {
+		if e["@message"] == "Finished waiting for node status" {
+			summary = e
+		}
+	}
+	if summary == nil {
+		t.Fatalf("no summary was logged, got %v", entries)
+	}
+	// Numbers are decoded as float64.
+	if summary["attempts"] != 3.0 || summary["retries"] != 1.0 {
+		t.Errorf("logged %v attempts and %v retries, want 3 and 1", summary["attempts"], summary["retries"])
+	}
+	if waited, err := time.ParseDuration(fmt.Sprint(summary["waited"])); err != nil || waited <= 0 {
+		t.Errorf("logged waited = %v, want the time spent between the checks", summary["waited"])
+	}
+}
+
+func TestClientLimitsConcurrentRequests(t *testing.T) {
+	const limit = 2
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if inFlight++; inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		writeJSON(w, http.StatusOK, `{"user_id": "user-1"}`)
+	}, ClientConfig{MaxConcurrentRequests: limit})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4*limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetUser(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > limit {
+		t.Errorf("%d requests were in flight at once, want at most %d", maxInFlight, limit)
+	}
+}
+
+func TestClientWaitsShareStatusChecks(t *testing.T) {
+	const nodes, pollInterval = 10, 20 * time.Millisecond
+
+	start := time.Now()
+	var mu sync.Mutex
+	var lists int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		status := "waiting_init"
+		if time.Since(start) > 5*pollInterval {
+			status = "running"
+		}
+		if r.Method == http.MethodPost {
+			var body struct {
+				NodeID string `json:"node_id"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			writeJSON(w, http.StatusOK, fmt.Sprintf(`{"node_id": %q, "status": %q}`, body.NodeID, status))
+
+			return
+		}
+
+		mu.Lock()
+		lists++
+		mu.Unlock()
+		var listed []string
+		for i := 0; i < nodes; i++ {
+			listed = append(listed, fmt.Sprintf(`{"node_id": "node-%d", "status": %q}`, i, status))
+		}
+		writeJSON(w, http.StatusOK, `{"nodes": [`+strings.Join(listed, ",")+`]}`)
+	}, ClientConfig{PollInterval: pollInterval, MaxPollInterval: pollInterval})
+
+	var wg sync.WaitGroup
+	for i := 0; i < nodes; i++ {
+		wg.Add(1)
+		go func(nodeID string) {
+			defer wg.Done()
+			if _, err := c.WaitNodeStatus(context.Background(), nodeID, "running", time.Minute); err != nil {
+				t.Error(err)
+			}
+		}(fmt.Sprintf("node-%d", i))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The listed statuses are reused for half a poll interval, so every
+	// interval lists twice at most instead of once per wait.
+	if max := int(elapsed/(pollInterval/2)) + 1; lists > max {
+		t.Errorf("listed nodes %d times in %s, want at most %d", lists, elapsed, max)
+	}
+}
+
+func TestClientWaitForStatus(t *testing.T) {
+	for name, tc := range map[string]struct {
+		// statuses are the ones listed by each status check, the last one
+		// repeats. An empty status means the node isn't listed yet.
+		statuses  []string
+		want      []string
+		wantLists int
+		wantErr   bool
+	}{
+		"already there": {
+			statuses:  []string{"running"},
+			want:      []string{"running"},
+			wantLists: 1,
+		},
+		"after a few statuses": {
+			statuses:  []string{"provisioning", "waiting_init", "starting", "running"},
+			want:      []string{"running"},
+			wantLists: 4,
+		},
+		"any of the wanted statuses": {
+			statuses:  []string{"provisioning", "waiting_unlock"},
+			want:      []string{"waiting_init", "waiting_unlock"},
+			wantLists: 2,
+		},
+		"not listed yet": {
+			statuses:  []string{"", "", "running"},
+			want:      []string{"running"},
+			wantLists: 3,
+		},
+		"never there": {
+			statuses: []string{"starting"},
+			want:     []string{"running"},
+			wantErr:  true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var lists int
+			status := func() string {
+				if lists > len(tc.statuses) {
+					return tc.statuses[len(tc.statuses)-1]
+				}
+
+				return tc.statuses[lists-1]
+			}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if r.Method == http.MethodPost {
+					writeJSON(w, http.StatusOK, fmt.Sprintf(`{"node_id": "node-1", "status": %q}`, status()))
+
+					return
+				}
+				lists++
+				if status() == "" {
+					writeJSON(w, http.StatusOK, `{"nodes": []}`)
+
+					return
+				}
+				writeJSON(w, http.StatusOK, fmt.Sprintf(`{"nodes": [{"node_id": "node-1", "status": %q}]}`, status()))
+			}, ClientConfig{PollInterval: time.Millisecond})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			node, err := c.waitForStatus(ctx, "node-1", tc.want...)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("got node %v, want the wait to time out", node.Status)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if lists != tc.wantLists {
+				t.Errorf("checked the status %d times, want %d", lists, tc.wantLists)
+			}
+			if node.Status == nil || *node.Status != status() {
+				t.Errorf("got a node with status %v, want %s", node.Status, status())
+			}
+		})
+	}
+}
+
+func TestClientRejectsEmptyBodies(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, "")
+	}, ClientConfig{})
+
+	ctx := context.Background()
+	for name, call := range map[string]func() error{
+		"create": func() error {
+			_, err := c.CreateNode(ctx, voltage.PostNodeCreateJSONRequestBody{Name: "node"}, nil)
+			return err
+		},
+		"read": func() error {
+			_, err := c.ReadNode(ctx, "node-1")
+			return err
+		},
+		"delete": func() error {
+			return c.DeleteNode(ctx, "node-1")
+		},
+	} {
+		if err := call(); !errors.Is(err, ErrInvalidAPIResponseBody) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrInvalidAPIResponseBody)
+		}
+	}
+}
+
+func TestClientRequiresNodeID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}, ClientConfig{})
+
+	ctx := context.Background()
+	for name, call := range map[string]func() error{
+		"read": func() error {
+			_, err := c.ReadNode(ctx, "")
+			return err
+		},
+		"delete": func() error {
+			return c.DeleteNode(ctx, "")
+		},
+		"wait": func() error {
+			_, err := c.WaitNodeStatus(ctx, "", "running", time.Minute)
+			return err
+		},
+		"logs": func() error {
+			_, _, err := c.NodeLogs(ctx, "")
+			return err
+		},
+		"connection": func() error {
+			_, err := c.NodeConnection(ctx, "", defaultMacaroonName)
+			return err
+		},
+	} {
+		if err := call(); !errors.Is(err, ErrNodeIDRequired) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrNodeIDRequired)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	const d, n = 10 * time.Second, 10000
+
+	rnd := rand.New(rand.NewSource(1))
+	var total time.Duration
+	for i := 0; i < n; i++ {
+		j := jitter(rnd, d)
+		if j < d*8/10 || j > d*12/10 {
+			t.Fatalf("jitter(%s) = %s, want within ±20%%", d, j)
+		}
+		total += j
+	}
+	if mean := total / n; mean < d*99/100 || mean > d*101/100 {
+		t.Errorf("jitter(%s) averages %s, want %s", d, mean, d)
+	}
+
+	if j := jitter(rnd, time.Nanosecond); j != time.Nanosecond {
+		t.Errorf("jitter(1ns) = %s, want it unchanged", j)
+	}
+}
+
+func TestClientPollIntervalGrows(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg  ClientConfig
+		want []time.Duration
+	}{
+		"defaults": {
+			want: []time.Duration{
+				time.Second, 1500 * time.Millisecond, 2250 * time.Millisecond, 3375 * time.Millisecond,
+				5 * time.Second, 5 * time.Second,
+			},
+		},
+		"configured": {
+			cfg: ClientConfig{PollInterval: 100 * time.Millisecond, MaxPollInterval: 200 * time.Millisecond},
+			want: []time.Duration{
+				100 * time.Millisecond, 150 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond,
+			},
+		},
+		"max below min": {
+			cfg:  ClientConfig{PollInterval: 10 * time.Second, MaxPollInterval: time.Second},
+			want: []time.Duration{10 * time.Second, 10 * time.Second},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newTestClient(t, nil, tc.cfg)
+
+			var got []time.Duration
+			for interval := c.pollInterval; len(got) < len(tc.want); interval = c.nextPollInterval(interval) {
+				got = append(got, interval)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("poll intervals = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	var mu sync.Mutex
+	var lists int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+
+			return
+		}
+
+		mu.Lock()
+		lists++
+		hang := lists == 1
+		mu.Unlock()
+		if hang {
+			// Hang until the client gives up.
+			<-r.Context().Done()
+
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "running"}]}`)
+	}, ClientConfig{PollInterval: time.Millisecond, RequestTimeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := c.WaitNodeStatus(context.Background(), "node-1", "running", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %s, want the hung status check to time out quickly", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if lists != 2 {
+		t.Errorf("checked the status %d times, want 2", lists)
+	}
+}
+
+func TestClientMaxRetries(t *testing.T) {
+	for name, tc := range map[string]struct {
+		maxRetries int
+		override   int
+		failures   int
+		wantCalls  int
+		wantErr    bool
+	}{
+		"no retries": {failures: 1, wantCalls: 1, wantErr: true},
+		"recovers":   {maxRetries: 2, failures: 2, wantCalls: 3},
+		"gives up":   {maxRetries: 2, failures: 3, wantCalls: 3, wantErr: true},
+		"override":   {maxRetries: 1, override: 3, failures: 3, wantCalls: 4},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var calls int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				calls++
+				fail := calls <= tc.failures
+				mu.Unlock()
+				if fail {
+					writeJSON(w, http.StatusServiceUnavailable, `{"message": "try again"}`)
+
+					return
+				}
+				writeJSON(w, http.StatusOK, `{"user_id": "user-1"}`)
+			}, ClientConfig{PollInterval: time.Millisecond, MaxRetries: tc.maxRetries})
+			c, err := c.derive(clientOverrides{maxRetries: tc.override})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.GetUser(context.Background())
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("err = %v, want an error: %t", err, tc.wantErr)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if calls != tc.wantCalls {
+				t.Errorf("called the API %d times, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientCreateNodeIsNotRetried(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		writeJSON(w, http.StatusBadGateway, `{"message": "bad gateway"}`)
+	}, ClientConfig{PollInterval: time.Millisecond, MaxRetries: 3})
+
+	if _, err := c.CreateNode(context.Background(), voltage.PostNodeCreateJSONRequestBody{}, nil); err == nil {
+		t.Fatal("a failed creation succeeded")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Errorf("sent the creation %d times, want 1", calls)
+	}
+}
+
+func TestClientTimeoutsNest(t *testing.T) {
+	cfg := ClientConfig{
+		PollInterval:   time.Millisecond,
+		RequestTimeout: 20 * time.Millisecond,
+		CreateTimeout:  200 * time.Millisecond,
+	}
+
+	t.Run("hung read is retried", func(t *testing.T) {
+		var mu sync.Mutex
+		var reads int
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "running"}]}`)
+
+				return
+			}
+
+			mu.Lock()
+			reads++
+			hang := reads == 1
+			mu.Unlock()
+			if hang {
+				// Hang until the client gives up, which is only noticed
+				// once the body is read.
+				_, _ = io.Copy(io.Discard, r.Body)
+				<-r.Context().Done()
+
+				return
+			}
+			writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+		}, cfg)
+
+		node, err := c.WaitNodeCreated(context.Background(), "node-1", "running")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if reads != 2 || node.Status == nil || *node.Status != "running" {
+			t.Errorf("got status %v after %d reads, want running after 2", node.Status, reads)
+		}
+	})
+
+	t.Run("wait timeout caps the retries", func(t *testing.T) {
+		var mu sync.Mutex
+		var lists int
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			lists++
+			mu.Unlock()
+			<-r.Context().Done()
+		}, cfg)
+
+		start := time.Now()
+		_, err := c.WaitNodeCreated(context.Background(), "node-1", "running")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want the wait to time out", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("waited %s, want the create timeout to cap the wait", elapsed)
+		}
+		// Each hung check timed out on its own and the next one was made.
+		mu.Lock()
+		defer mu.Unlock()
+		if lists < 2 {
+			t.Errorf("checked the status %d times, want the timed out checks retried", lists)
+		}
+	})
+}
+
+func TestClientTokenOverrideAuthHeader(t *testing.T) {
+	var header http.Header
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		writeJSON(w, http.StatusOK, `{"user_id": "user-1"}`)
+	}, ClientConfig{AuthHeader: "Authorization", AuthScheme: "Bearer"})
+
+	c, err := c.derive(clientOverrides{token: "node-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetUser(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("Authorization"); got != "Bearer node-token" {
+		t.Errorf("Authorization = %q, want the node token in the configured scheme", got)
+	}
+}
+
+func TestClientNodeNotFound(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status       int
+		body         string
+		wantNotFound bool
+	}{
+		"404":                    {http.StatusNotFound, `{"message": "Not Found"}`, true},
+		"400 node_id is invalid": {http.StatusBadRequest, `{"message": "node_id is invalid"}`, true},
+		"400 node_id not valid":  {http.StatusBadRequest, `{"message": "node_id is not valid"}`, true},
+		"400 other":              {http.StatusBadRequest, `{"message": "maxchansize is invalid"}`, false},
+		"500 node_id is invalid": {http.StatusInternalServerError, `{"message": "node_id is invalid"}`, false},
+		"unauthorized":           {http.StatusUnauthorized, `{"message": "Not authorized"}`, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tc.status, tc.body)
+			}, ClientConfig{})
+
+			ctx := context.Background()
+			_, readErr := c.ReadNode(ctx, "node-1")
+			deleteErr := c.DeleteNode(ctx, "node-1")
+			for op, err := range map[string]error{"read": readErr, "delete": deleteErr} {
+				var cErr *ClientError
+				if !errors.As(err, &cErr) {
+					t.Fatalf("%s: err = %v, want a ClientError", op, err)
+				}
+				if got := errors.Is(err, ErrNodeNotFound); got != tc.wantNotFound {
+					t.Errorf("%s: errors.Is(%v, ErrNodeNotFound) = %t, want %t", op, err, got, tc.wantNotFound)
+				}
+			}
+		})
+	}
+}
+
+func TestClientAcceptsAny2xx(t *testing.T) {
+	for _, tc := range []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusCreated, false},
+		{http.StatusAccepted, false},
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+	} {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, tc.status, `{"user_id": "user-1"}`)
+		}, ClientConfig{})
+
+		user, err := c.GetUser(context.Background())
+		if !tc.wantErr {
+			if err != nil {
+				t.Errorf("%d: %v", tc.status, err)
+			} else if user.UserId == nil || *user.UserId != "user-1" {
+				t.Errorf("%d: got user %v, want user-1", tc.status, user.UserId)
+			}
+			continue
+		}
+
+		var cErr *ClientError
+		if !errors.As(err, &cErr) || cErr.statusCode != tc.status {
+			t.Errorf("%d: err = %v, want a ClientError with the status code", tc.status, err)
+		} else if !strings.Contains(err.Error(), fmt.Sprint(tc.status)) {
+			t.Errorf("%d: error %q doesn't mention the status code", tc.status, err)
+		}
+	}
+}
+
+func TestClientMaxPollAttempts(t *testing.T) {
+	for name, tc := range map[string]struct {
+		attempts  int
+		timeout   time.Duration
+		wantLists int
+		wantErr   error
+	}{
+		"attempts run out first": {
+			attempts:  3,
+			timeout:   time.Minute,
+			wantLists: 3,
+		},
+		"timeout first": {
+			attempts: 1000,
+			timeout:  20 * time.Millisecond,
+			wantErr:  context.DeadlineExceeded,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var lists int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				lists++
+				mu.Unlock()
+				writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "starting"}]}`)
+			}, ClientConfig{PollInterval: time.Millisecond, MaxPollInterval: time.Millisecond, MaxPollAttempts: tc.attempts})
+
+			_, err := c.WaitNodeStatus(context.Background(), "node-1", "running", tc.timeout)
+			if err == nil {
+				t.Fatal("the wait succeeded")
+			}
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("err = %v, want %v", err, tc.wantErr)
+				}
+
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if lists != tc.wantLists {
+				t.Errorf("checked the status %d times, want %d", lists, tc.wantLists)
+			}
+			if !strings.Contains(err.Error(), `last status was "starting"`) {
+				t.Errorf("error %q doesn't report the last status", err)
+			}
+		})
+	}
+}
+
+func TestClientReadsIncompleteNodeAgain(t *testing.T) {
+	for name, tc := range map[string]struct {
+		incomplete, wantReads int
+		wantErr               bool
+	}{
+		"complete":          {incomplete: 0, wantReads: 1},
+		"incomplete once":   {incomplete: 1, wantReads: 2},
+		"always incomplete": {incomplete: 100, wantReads: incompleteReadRetries + 1, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var reads int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if reads++; reads <= tc.incomplete {
+					writeJSON(w, http.StatusOK, `{"node_id": "node-1"}`)
+
+					return
+				}
+				writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+			}, ClientConfig{PollInterval: time.Millisecond})
+
+			node, err := c.ReadNode(context.Background(), "node-1")
+			if reads != tc.wantReads {
+				t.Errorf("read the node %d times, want %d", reads, tc.wantReads)
+			}
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidAPIResponseBody) {
+					t.Errorf("err = %v, want %v", err, ErrInvalidAPIResponseBody)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node.Status == nil || *node.Status != "running" {
+				t.Errorf("status = %v, want running", node.Status)
+			}
+		})
+	}
+}
+
+func TestClientCreateNode(t *testing.T) {
+	api := fakevoltage.NewServer()
+	t.Cleanup(api.Close)
+	c := newTestClient(t, api.Config.Handler.ServeHTTP, ClientConfig{PollInterval: time.Millisecond})
+
+	ctx := context.Background()
+	body := voltage.PostNodeCreateJSONRequestBody{
+		Name:          "node",
+		Network:       "testnet",
+		PurchasedType: "paid",
+		Type:          "standard",
+		Settings:      voltage.NodeSettings{Alias: toPtr("node"), Whitelist: &[]string{"1.2.3.4"}},
+	}
+	nodeID, err := c.CreateNode(ctx, body, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote, ok := api.Node(nodeID); !ok || *remote.NodeName != "node" || *remote.Settings.Alias != "node" {
+		t.Fatalf("node %s wasn't created as requested", nodeID)
+	}
+
+	for _, status := range []string{"", "running"} {
+		node, err := c.WaitNodeCreated(ctx, nodeID, status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := status
+		if want == "" {
+			// Returned right away.
+			want = "waiting_init"
+		}
+		if node.NodeId == nil || *node.NodeId != nodeID || node.Status == nil || *node.Status != want ||
+			node.ApiEndpoint == nil || node.LndVersion == nil {
+			t.Errorf("waiting for %q returned %+v", status, node)
+		}
+	}
+}
+
+func TestClientCreateNodeExtraParams(t *testing.T) {
+	var body map[string]json.RawMessage
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("invalid body %s: %v", b, err)
+		}
+		writeJSON(w, http.StatusOK, `{"node_id": "node-1"}`)
+	}, ClientConfig{})
+
+	ctx := context.Background()
+	_, err := c.CreateNode(ctx, voltage.PostNodeCreateJSONRequestBody{Name: "node", Network: "testnet"}, map[string]json.RawMessage{
+		"name":   json.RawMessage(`"other"`),
+		"coupon": json.RawMessage(`"FREE"`),
+		"backup": json.RawMessage(`{"enabled": true}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{
+		"name":    `"node"`,
+		"network": `"testnet"`,
+		"coupon":  `"FREE"`,
+		"backup":  `{"enabled":true}`,
+	} {
+		if got := string(body[k]); got != want {
+			t.Errorf("%s = %s, want %s", k, got, want)
+		}
+	}
+
+	_, err = c.CreateNode(ctx, voltage.PostNodeCreateJSONRequestBody{Name: "node"}, map[string]json.RawMessage{
+		"coupon": json.RawMessage(`FREE`),
+	})
+	if err == nil {
+		t.Error("creating a node with an invalid extra field succeeded")
+	}
+}
+
+func TestClientCreateNodeWithoutID(t *testing.T) {
+	for name, tc := range map[string]struct {
+		list       string
+		status     int
+		wantID     string
+		wantErr    error
+		wantStatus int
+	}{
+		"created": {
+			list:   `{"nodes": [{"node_id": "node-1", "node_name": "node", "network": "testnet", "status": "waiting_init"}]}`,
+			status: http.StatusOK,
+			wantID: "node-1",
+		},
+		"only deleted": {
+			list:    `{"nodes": [{"node_id": "node-1", "node_name": "node", "network": "testnet", "status": "deleted"}]}`,
+			status:  http.StatusOK,
+			wantErr: ErrInvalidAPIResponseBody,
+		},
+		"listing fails": {
+			list:       `{"message": "Not authorized"}`,
+			status:     http.StatusUnauthorized,
+			wantStatus: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/node/create":
+					writeJSON(w, http.StatusOK, `{"network": "testnet"}`)
+				case r.Method == http.MethodGet:
+					writeJSON(w, tc.status, tc.list)
+				default:
+					writeJSON(w, http.StatusOK, `{"node_id": "node-1", "node_name": "node", "network": "testnet", "status": "waiting_init"}`)
+				}
+			}, ClientConfig{})
+
+			id, err := c.CreateNode(context.Background(), voltage.PostNodeCreateJSONRequestBody{Name: "node", Network: "testnet"}, nil)
+			if tc.wantStatus != 0 {
+				// The lookup error is kept, not replaced by a generic one.
+				var cErr *ClientError
+				if !errors.As(err, &cErr) || cErr.statusCode != tc.wantStatus {
+					t.Errorf("err = %v, want the %d answered to the lookup", err, tc.wantStatus)
+				}
+
+				return
+			}
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("err = %v, want %v", err, tc.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tc.wantID {
+				t.Errorf("node_id = %q, want %q", id, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestClientUpgradeNode(t *testing.T) {
+	for name, tc := range map[string]struct {
+		// listed are the status and LND version listed by each status
+		// check, the last one repeats.
+		listed    [][2]string
+		wantLists int
+	}{
+		"restarts": {
+			listed:    [][2]string{{"running", "v0.16.4-beta"}, {"starting", "v0.16.4-beta"}, {"running", "v0.17.0-beta"}},
+			wantLists: 3,
+		},
+		"restarts with the new version": {
+			listed:    [][2]string{{"running", "v0.16.4-beta"}, {"stopping", "v0.17.0-beta"}, {"running", "v0.17.0-beta"}},
+			wantLists: 3,
+		},
+		"already upgraded": {
+			listed:    [][2]string{{"running", "0.17.0-beta"}},
+			wantLists: 2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var lists int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch {
+				case r.URL.Path == "/node/update":
+					writeJSON(w, http.StatusOK, `{"node_id": "node-1", "lnd_version": "v0.17.0-beta"}`)
+				case r.Method == http.MethodPost:
+					writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+				default:
+					lists++
+					listed := tc.listed[len(tc.listed)-1]
+					if lists <= len(tc.listed) {
+						listed = tc.listed[lists-1]
+					}
+					writeJSON(w, http.StatusOK, fmt.Sprintf(`{"nodes": [{"node_id": "node-1", "status": %q, "lnd_version": %q}]}`,
+						listed[0], listed[1]))
+				}
+			}, ClientConfig{PollInterval: time.Millisecond})
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			version, err := c.UpgradeNode(ctx, "node-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != "v0.17.0-beta" {
+				t.Errorf("version = %s, want v0.17.0-beta", version)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			// Returning on the first listing would take the node still
+			// running the old version as upgraded.
+			if lists < tc.wantLists {
+				t.Errorf("returned after %d status checks, want at least %d", lists, tc.wantLists)
+			}
+		})
+	}
+}
+
+func TestClientUpdateWhitelist(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status    int
+		body      string
+		wantErr   bool
+		wantEntry string
+	}{
+		"accepted":         {status: http.StatusOK, body: `{"node_id": "node-1", "whitelist": ["1.2.3.4", "10.0.0.0/8"]}`},
+		"entry rejected":   {status: http.StatusBadRequest, body: `{"message": "10.0.0.0/8: invalid IP"}`, wantErr: true, wantEntry: "10.0.0.0/8"},
+		"no entry named":   {status: http.StatusBadRequest, body: `{"message": "node_id is invalid"}`, wantErr: true},
+		"unrelated number": {status: http.StatusBadRequest, body: `{"message": "maximum of 2 entries"}`, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var body map[string]any
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/node/whitelist" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid body: %v", err)
+				}
+				writeJSON(w, tc.status, tc.body)
+			}, ClientConfig{})
+
+			err := c.UpdateWhitelist(context.Background(), "node-1", []string{"1.2.3.4", "10.0.0.0/8"})
+			if want := []any{"1.2.3.4", "10.0.0.0/8"}; !reflect.DeepEqual(body["whitelist"], want) {
+				t.Errorf("sent whitelist %v, want %v", body["whitelist"], want)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tc.wantErr)
+			}
+
+			var wErr *WhitelistEntryError
+			switch {
+			case tc.wantEntry == "" && errors.As(err, &wErr):
+				t.Errorf("err = %v, want it not to name an entry", err)
+			case tc.wantEntry != "" && !errors.As(err, &wErr):
+				t.Errorf("err = %v, want a WhitelistEntryError", err)
+			case tc.wantEntry != "" && wErr.Entry != tc.wantEntry:
+				t.Errorf("rejected entry = %q, want %q", wErr.Entry, tc.wantEntry)
+			}
+			// The API error stays available to errToDiags.
+			var cErr *ClientError
+			if tc.wantErr && !errors.As(err, &cErr) {
+				t.Errorf("err = %v, want it to wrap a ClientError", err)
+			}
+		})
+	}
+}
+
+func TestFindWhitelistEntry(t *testing.T) {
+	ips := []string{"1.2.3.4", "10.0.0.0/8", "2001:db8::1"}
+	for msg, want := range map[string]string{
+		"10.0.0.0/8: invalid IP":                 "10.0.0.0/8",
+		"IP 1.2.3.4 is not allowed.":             "1.2.3.4",
+		"192.168.000.001 and 1.2.3.004 rejected": "1.2.3.4",
+		"invalid whitelist entry 2001:DB8::1":    "2001:db8::1",
+		"whitelist can't contain 5.6.7.8":        "",
+		"maximum of 2 entries":                   "",
+		"":                                       "",
+	} {
+		got, ok := findWhitelistEntry(msg, ips)
+		if got != want || ok != (want != "") {
+			t.Errorf("findWhitelistEntry(%q) = %q, %t, want %q", msg, got, ok, want)
+		}
+	}
+}
+
+func TestClientRetryCaps(t *testing.T) {
+	// failing answers failures status checks with a 503, and then lists the
+	// node as running.
+	failing := func(failures int) http.HandlerFunc {
+		var mu sync.Mutex
+		var lists int
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				writeJSON(w, http.StatusOK, `{"node_id": "node-1", "status": "running"}`)
+
+				return
+			}
+
+			mu.Lock()
+			lists++
+			failed := lists <= failures
+			mu.Unlock()
+			if failed {
+				writeJSON(w, http.StatusServiceUnavailable, `{"message": "unavailable"}`)
+
+				return
+			}
+			writeJSON(w, http.StatusOK, `{"nodes": [{"node_id": "node-1", "status": "running"}]}`)
+		}
+	}
+
+	t.Run("max elapsed", func(t *testing.T) {
+		c := newTestClient(t, failing(math.MaxInt), ClientConfig{
+			PollInterval:    time.Millisecond,
+			MaxPollInterval: 5 * time.Millisecond,
+			RetryMaxElapsed: 50 * time.Millisecond,
+		})
+
+		start := time.Now()
+		_, err := c.WaitNodeStatus(context.Background(), "node-1", "running", time.Minute)
+		if err == nil || !strings.Contains(err.Error(), "gave up after") {
+			t.Fatalf("err = %v, want to give up retrying", err)
+		}
+		if !strings.Contains(err.Error(), "503") {
+			t.Errorf("error %q doesn't include the last failure", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("gave up after %s, want retry_max_elapsed to bound the retries", elapsed)
+		}
+	})
+
+	t.Run("max backoff", func(t *testing.T) {
+		// Without the cap, the three retries would wait for over a second.
+		c := newTestClient(t, failing(3), ClientConfig{
+			PollInterval:    200 * time.Millisecond,
+			MaxPollInterval: time.Minute,
+			RetryMaxBackoff: time.Millisecond,
+		})
+
+		start := time.Now()
+		if _, err := c.WaitNodeStatus(context.Background(), "node-1", "running", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+			t.Errorf("waited %s, want retry_max_backoff to cap the waits between retries", elapsed)
+		}
+	})
+}
+
+// writeJSON answers with status and the JSON body.
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultMacaroonName is the name of the macaroon Voltage backs up when a
+// node is initialized.
+const defaultMacaroonName = "admin"
+
+// The ports Voltage serves the node APIs on, which are the LND defaults. The
+// API only reports the host of the node.
+const (
+	defaultGRPCPort = 10009
+	defaultRESTPort = 8080
+)
+
+var nodeConnectionDataSourceSchema = schema.Schema{
+	Description: "Retrieves what clients need to connect to a node, e.g. to template a client configuration",
+	Attributes: map[string]schema.Attribute{
+		"node_id": schema.StringAttribute{
+			Description: "Unique ID for the node",
+			Required:    true,
+		},
+		"macaroon_name": schema.StringAttribute{
+			Description: "Name of the backed up macaroon to return. Defaults to " + defaultMacaroonName + ".",
+			Optional:    true,
+		},
+		"endpoint": schema.StringAttribute{
+			Description: "API Endpoint for the node",
+			Computed:    true,
+		},
+		"host": schema.StringAttribute{
+			Description: "Host of the node APIs, i.e. the endpoint without any port",
+			Computed:    true,
+		},
+		"grpc_port": schema.Int64Attribute{
+			Description: "Port of the node gRPC API",
+			Computed:    true,
+		},
+		"rest_port": schema.Int64Attribute{
+			Description: "Port of the node REST API",
+			Computed:    true,
+		},
+		"tls_cert": schema.StringAttribute{
+			Description: "Base64 encoded TLS certificate of the node",
+			Computed:    true,
+		},
+		"encrypted_macaroon": schema.StringAttribute{
+			Description: "The macaroon, encrypted with the account password as Voltage only stores it encrypted. " +
+				"Null if no macaroon with that name was backed up.",
+			Computed:  true,
+			Sensitive: true,
+		},
+	},
+}
+
+type nodeConnectionDataSourceModel struct {
+	NodeID            types.String `tfsdk:"node_id"`
+	MacaroonName      types.String `tfsdk:"macaroon_name"`
+	Endpoint          types.String `tfsdk:"endpoint"`
+	Host              types.String `tfsdk:"host"`
+	GRPCPort          types.Int64  `tfsdk:"grpc_port"`
+	RESTPort          types.Int64  `tfsdk:"rest_port"`
+	TLSCert           types.String `tfsdk:"tls_cert"`
+	EncryptedMacaroon types.String `tfsdk:"encrypted_macaroon"`
+}
+
+type NodeConnectionDataSource struct {
+	client *Client
+}
+
+func NewNodeConnectionDataSource() datasource.DataSource {
+	return &NodeConnectionDataSource{}
+}
+
+func (d *NodeConnectionDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_node_connection"
+}
+
+func (d *NodeConnectionDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = nodeConnectionDataSourceSchema
+}
+
+func (d *NodeConnectionDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = data.client
+}
+
+func (d *NodeConnectionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state nodeConnectionDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := defaultMacaroonName
+	if !state.MacaroonName.IsNull() {
+		name = state.MacaroonName.ValueString()
+	}
+
+	conn, err := d.client.NodeConnection(ctx, state.NodeID.ValueString(), name)
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	state.Endpoint = types.StringValue(conn.Endpoint)
+	state.Host = types.StringValue(endpointHost(conn.Endpoint))
+	state.GRPCPort = types.Int64Value(defaultGRPCPort)
+	state.RESTPort = types.Int64Value(defaultRESTPort)
+	state.TLSCert = types.StringValue(conn.TLSCert)
+	state.EncryptedMacaroon = types.StringNull()
+	if conn.Macaroon != "" {
+		state.EncryptedMacaroon = types.StringValue(conn.Macaroon)
+	}
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
+
+// endpointHost returns the host of endpoint, which may carry a port.
+func endpointHost(endpoint string) string {
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+
+	return endpoint
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+func TestNodeConnectionDataSourceRead(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(map[string]any{"wait_for_status": "running"}))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	v, diags := p.readDataSource("voltage_node_connection", map[string]any{"node_id": nodeID})
+	requireNoErrors(t, diags)
+
+	var got nodeConnectionDataSourceModel
+	if diags := (tfsdk.State{Schema: nodeConnectionDataSourceSchema, Raw: v}).Get(context.Background(), &got); diags.HasError() {
+		t.Fatalf("decoding data source state: %v", diags)
+	}
+	if got.Endpoint.ValueString() != "test-node.m.voltageapp.io" || got.Host.ValueString() != "test-node.m.voltageapp.io" {
+		t.Errorf("endpoint = %s, host = %s, want test-node.m.voltageapp.io", got.Endpoint, got.Host)
+	}
+	if got.GRPCPort.ValueInt64() != defaultGRPCPort || got.RESTPort.ValueInt64() != defaultRESTPort {
+		t.Errorf("grpc_port = %s, rest_port = %s, want %d and %d", got.GRPCPort, got.RESTPort, defaultGRPCPort, defaultRESTPort)
+	}
+	if got.TLSCert.ValueString() == "" || got.EncryptedMacaroon.ValueString() == "" {
+		t.Errorf("tls_cert = %s, encrypted_macaroon = %s, want both set", got.TLSCert, got.EncryptedMacaroon)
+	}
+}
+
+func TestEndpointHost(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"node.m.voltageapp.io":       "node.m.voltageapp.io",
+		"node.m.voltageapp.io:10009": "node.m.voltageapp.io",
+		"[::1]:8080":                 "::1",
+	} {
+		if got := endpointHost(endpoint); got != want {
+			t.Errorf("endpointHost(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
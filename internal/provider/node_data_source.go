@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+var nodeDataSourceSchema = schema.Schema{
+	Description: "Looks up a node in Voltage by its name or ID. Its settings can be used to create a node " +
+		"with the same ones, e.g. settings = merge(data.voltage_node.src.settings, { alias = \"copy\" })",
+	Attributes: map[string]schema.Attribute{
+		"name": schema.StringAttribute{
+			Description: "User defined node name given at creation",
+			Optional:    true,
+			Computed:    true,
+			Validators: []validator.String{
+				stringvalidator.ExactlyOneOf(path.MatchRoot("node_id")),
+			},
+		},
+		"node_id": schema.StringAttribute{
+			Description: "Unique ID for the node",
+			Optional:    true,
+			Computed:    true,
+		},
+		"network": schema.StringAttribute{
+			Description: "Network the node is running on",
+			Computed:    true,
+		},
+		"purchased_type": schema.StringAttribute{
+			Description: "Purchase type of the node",
+			Computed:    true,
+		},
+		"type": schema.StringAttribute{
+			Description: "Type of node",
+			Computed:    true,
+		},
+		"status": schema.StringAttribute{
+			Description: "Status of the node",
+			Computed:    true,
+		},
+		"created": schema.StringAttribute{
+			Description: "Date that the node was created",
+			Computed:    true,
+		},
+		"expires": schema.StringAttribute{
+			Description: "Date that the node expires",
+			Computed:    true,
+		},
+		"api_endpoint": schema.StringAttribute{
+			Description: "API Endpoint for the node",
+			Computed:    true,
+		},
+		"lnd_version": schema.StringAttribute{
+			Description: "Version of LND the node is running",
+			Computed:    true,
+		},
+		"settings": schema.SingleNestedAttribute{
+			Description: "Settings for the Lightning Node",
+			Computed:    true,
+			Attributes:  settingsAttributes[schema.Attribute](true),
+		},
+	},
+}
+
+type nodeDataSourceModel struct {
+	Name          types.String       `tfsdk:"name"`
+	NodeID        types.String       `tfsdk:"node_id"`
+	Network       types.String       `tfsdk:"network"`
+	PurchasedType types.String       `tfsdk:"purchased_type"`
+	Type          types.String       `tfsdk:"type"`
+	Status        types.String       `tfsdk:"status"`
+	Created       types.String       `tfsdk:"created"`
+	Expires       types.String       `tfsdk:"expires"`
+	APIEndpoint   types.String       `tfsdk:"api_endpoint"`
+	LndVersion    types.String       `tfsdk:"lnd_version"`
+	Settings      *nodeSettingsModel `tfsdk:"settings"`
+}
+
+type NodeDataSource struct {
+	client *Client
+}
+
+func NewNodeDataSource() datasource.DataSource {
+	return &NodeDataSource{}
+}
+
+func (d *NodeDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_node"
+}
+
+func (d *NodeDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = nodeDataSourceSchema
+}
+
+func (d *NodeDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = data.client
+}
+
+func (d *NodeDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state nodeDataSourceModel
+
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var node *voltage.NodeDocument
+	var err error
+	if !state.NodeID.IsNull() {
+		node, err = d.client.ReadNode(ctx, state.NodeID.ValueString())
+	} else {
+		node, err = d.client.FindNodeByName(ctx, state.Name.ValueString())
+	}
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	state.Name = types.StringPointerValue(node.NodeName)
+	state.NodeID = types.StringPointerValue(node.NodeId)
+	state.Network = types.StringPointerValue(node.Network)
+	state.PurchasedType = types.StringPointerValue(node.PurchasedType)
+	state.Type = types.StringPointerValue(node.Type)
+	state.Status = types.StringPointerValue(node.Status)
+	state.Created = types.StringPointerValue(node.Created)
+	state.Expires = types.StringPointerValue(node.Expires)
+	state.APIEndpoint = types.StringPointerValue(node.ApiEndpoint)
+	state.LndVersion = types.StringPointerValue(node.LndVersion)
+	if node.Settings != nil {
+		state.Settings = &nodeSettingsModel{}
+		state.Settings.setFromAPI(node.Settings)
+	}
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestNodeDataSourceSettingsMatchResource(t *testing.T) {
+	settings := nodeDataSourceSchema.Attributes["settings"].GetType().(types.ObjectType).AttrTypes
+	for name, a := range nodeSettingsSchemaAttributes {
+		if typ, ok := settings[name]; !ok || !typ.Equal(a.GetType()) {
+			t.Errorf("data source setting %s = %v, want %v", name, typ, a.GetType())
+		}
+	}
+	if len(settings) != len(nodeSettingsSchemaAttributes) {
+		t.Errorf("data source has %d settings, want %d", len(settings), len(nodeSettingsSchemaAttributes))
+	}
+}
+
+func TestNodeDataSourceRead(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(map[string]any{
+		"name":     "source",
+		"settings": testSettings(map[string]any{"alias": "src", "whitelist": []any{"10.0.0.1/32", " 1.2.3.4", "1.2.3.4"}}),
+	})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	for name, lookup := range map[string]map[string]any{
+		"by id":   {"node_id": nodeID},
+		"by name": {"name": "source"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			v, diags := p.readDataSource("voltage_node", lookup)
+			requireNoErrors(t, diags)
+
+			var got nodeDataSourceModel
+			if diags := (tfsdk.State{Schema: nodeDataSourceSchema, Raw: v}).Get(context.Background(), &got); diags.HasError() {
+				t.Fatalf("decoding data source state: %v", diags)
+			}
+			if got.NodeID.ValueString() != nodeID || got.Name.ValueString() != "source" {
+				t.Errorf("read node %s (%s), want %s (source)", got.NodeID, got.Name, nodeID)
+			}
+			if got.Settings == nil {
+				t.Fatal("settings are null")
+			}
+			if got.Settings.Alias.ValueString() != "src" || !got.Settings.Grpc.ValueBool() {
+				t.Errorf("settings don't match the node ones: %+v", got.Settings)
+			}
+			if want := []types.String{types.StringValue("10.0.0.1/32"), types.StringValue("1.2.3.4")}; !equalStrings(got.Settings.Whitelist, want) {
+				t.Errorf("whitelist = %v, want %v", got.Settings.Whitelist, want)
+			}
+		})
+	}
+}
+
+func TestNodeDataSourceRequiresOneLookup(t *testing.T) {
+	p := newTestProvider(t, nil)
+	for name, lookup := range map[string]map[string]any{
+		"neither": {},
+		"both":    {"node_id": "node-1", "name": "source"},
+	} {
+		_, diags := p.readDataSource("voltage_node", lookup)
+		if findDiag(diags, tfprotov6.DiagnosticSeverityError, "Invalid Attribute Combination") == nil {
+			t.Errorf("%s: got %v, want an invalid attribute combination error", name, summaries(diags))
+		}
+	}
+}
+
+func equalStrings(a, b []types.String) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestNodeDataSourceCloneSettings(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(map[string]any{
+		"name":     "source",
+		"settings": testSettings(map[string]any{"alias": "src", "wumbo": true, "maxchansize": "500000"}),
+	})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	source, _ := p.api.Node(nodeState(t, state).NodeID.ValueString())
+
+	// settings = merge(data.voltage_node.src.settings, { alias = "clone" })
+	v, diags := p.readDataSource("voltage_node", map[string]any{"node_id": *source.NodeId})
+	requireNoErrors(t, diags)
+	var attrs, settings map[string]tftypes.Value
+	if err := v.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if err := attrs["settings"].As(&settings); err != nil {
+		t.Fatal(err)
+	}
+	settings["alias"] = tftypes.NewValue(tftypes.String, "clone")
+
+	config = testNodeConfig(map[string]any{
+		"name":     "clone",
+		"settings": tftypes.NewValue(attrs["settings"].Type(), settings),
+	})
+	state, diags = p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+
+	clone, _ := p.api.Node(nodeState(t, state).NodeID.ValueString())
+	want := *source.Settings
+	want.Alias = toPtr("clone")
+	if !reflect.DeepEqual(*clone.Settings, want) {
+		t.Errorf("clone settings = %+v, want %+v", *clone.Settings, want)
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maxNodeLogLines is the number of log lines Voltage keeps for a node.
+const maxNodeLogLines = 100
+
+var nodeLogsDataSourceSchema = schema.Schema{
+	Description: "Retrieves the most recent LND logs of a node",
+	Attributes: map[string]schema.Attribute{
+		"node_id": schema.StringAttribute{
+			Description: "Unique ID for the node",
+			Required:    true,
+		},
+		"lines": schema.Int64Attribute{
+			Description: fmt.Sprintf("Number of log lines to return, counting from the most recent one. "+
+				"Voltage keeps the last %d, which is the default.", maxNodeLogLines),
+			Optional: true,
+			Validators: []validator.Int64{
+				int64validator.Between(1, maxNodeLogLines),
+			},
+		},
+		"logs": schema.StringAttribute{
+			Description: "The log lines, oldest first, separated by newlines",
+			Computed:    true,
+		},
+		"last_modified": schema.StringAttribute{
+			Description: "Date of the most recent log line",
+			Computed:    true,
+		},
+	},
+}
+
+type nodeLogsDataSourceModel struct {
+	NodeID       types.String `tfsdk:"node_id"`
+	Lines        types.Int64  `tfsdk:"lines"`
+	Logs         types.String `tfsdk:"logs"`
+	LastModified types.String `tfsdk:"last_modified"`
+}
+
+type NodeLogsDataSource struct {
+	client *Client
+}
+
+func NewNodeLogsDataSource() datasource.DataSource {
+	return &NodeLogsDataSource{}
+}
+
+func (d *NodeLogsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_node_logs"
+}
+
+func (d *NodeLogsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = nodeLogsDataSourceSchema
+}
+
+func (d *NodeLogsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = data.client
+}
+
+func (d *NodeLogsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state nodeLogsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	nodeID := state.NodeID.ValueString()
+	lines, lastModified, err := d.client.NodeLogs(ctx, nodeID)
+	if err != nil {
+		var cErr *ClientError
+		if errors.As(err, &cErr) && cErr.statusCode >= 400 && cErr.statusCode < 500 && !errors.Is(err, ErrNodeNotFound) {
+			// Logs are missing until LND started at least once, tell
+			// whether that is the case.
+			if node, nErr := d.client.ReadNode(ctx, nodeID); nErr == nil && node.Status != nil {
+				resp.Diagnostics.AddError(
+					"Node logs not available",
+					fmt.Sprintf("Voltage has no logs for node %s, whose status is %q: %s", nodeID, *node.Status, err),
+				)
+
+				return
+			}
+		}
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	if n := int(state.Lines.ValueInt64()); n > 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	state.Logs = types.StringValue(strings.Join(lines, "\n"))
+	state.LastModified = types.StringNull()
+	if lastModified != "" {
+		state.LastModified = types.StringValue(lastModified)
+	}
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+func TestNodeLogsDataSourceRead(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	read := func(config map[string]any) nodeLogsDataSourceModel {
+		t.Helper()
+
+		v, diags := p.readDataSource("voltage_node_logs", config)
+		requireNoErrors(t, diags)
+
+		var got nodeLogsDataSourceModel
+		if diags := (tfsdk.State{Schema: nodeLogsDataSourceSchema, Raw: v}).Get(context.Background(), &got); diags.HasError() {
+			t.Fatalf("decoding data source state: %v", diags)
+		}
+
+		return got
+	}
+
+	got := read(map[string]any{"node_id": nodeID})
+	if lines := strings.Split(got.Logs.ValueString(), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "CRTR") {
+		t.Errorf("logs = %q, want the 2 lines of the node, oldest first", got.Logs.ValueString())
+	}
+	if got.LastModified.IsNull() {
+		t.Error("last_modified wasn't set")
+	}
+
+	got = read(map[string]any{"node_id": nodeID, "lines": 1})
+	if logs := got.Logs.ValueString(); strings.Contains(logs, "\n") || !strings.Contains(logs, "CRTR") {
+		t.Errorf("logs = %q, want the most recent line only", logs)
+	}
+
+	_, diags = p.readDataSource("voltage_node_logs", map[string]any{"node_id": nodeID, "lines": maxNodeLogLines + 1})
+	if !hasErrors(diags) {
+		t.Errorf("lines above %d are accepted", maxNodeLogLines)
+	}
+}
+
+func TestNodeLogsDataSourceNotAvailable(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	// Voltage has no logs for nodes LND didn't start on yet.
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/node/logs" {
+			writeJSON(w, http.StatusBadRequest, `{"message": "logs not found"}`)
+
+			return
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(api.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": api.URL}))
+
+	_, diags = p.readDataSource("voltage_node_logs", map[string]any{"node_id": nodeID})
+	d := findDiag(diags, tfprotov6.DiagnosticSeverityError, "Node logs not available")
+	if d == nil || !strings.Contains(d.Detail, `"waiting_init"`) {
+		t.Errorf("got %v, want an error telling the node status", summaries(diags))
+	}
+
+	// Unknown nodes are reported as such.
+	_, diags = p.readDataSource("voltage_node_logs", map[string]any{"node_id": "unknown"})
+	if !hasErrors(diags) || findDiag(diags, tfprotov6.DiagnosticSeverityError, "Node logs not available") != nil {
+		t.Errorf("got %v, want the node not found error", summaries(diags))
+	}
+}
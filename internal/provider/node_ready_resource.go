@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var nodeReadySchema = schema.Schema{
+	Description: "Waits for a node to reach a status. Combined with wait_for_ready = false on voltage_node, it " +
+		"lets other resources depend on the node being created and on it being ready separately.",
+	Attributes: map[string]schema.Attribute{
+		"node_id": schema.StringAttribute{
+			Description: "Unique ID of the node to wait for",
+			Required:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"status": schema.StringAttribute{
+			Description: "Status to wait for, one of 'waiting_init' (default), 'waiting_unlock' or 'running'. " +
+				"The provider doesn't initialize nor unlock wallets, see wait_for_status on voltage_node.",
+			Optional: true,
+			Computed: true,
+			Default:  stringdefault.StaticString("waiting_init"),
+			Validators: []validator.String{
+				stringvalidator.OneOf("waiting_init", "waiting_unlock", "running"),
+			},
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"timeout": schema.StringAttribute{
+			Description: "How long to wait for the status, such as 30m. Defaults to the node creation timeout.",
+			Optional:    true,
+			Validators: []validator.String{
+				durationValidator{},
+			},
+		},
+		"token": schema.StringAttribute{
+			Description: "API token used to read the node instead of the provider one, for nodes owned by " +
+				"another account. Set it to the token of the voltage_node.",
+			Optional:  true,
+			Sensitive: true,
+		},
+		"reached_at": schema.StringAttribute{
+			Description: "When the node was seen in the wanted status, in RFC3339 format",
+			Computed:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+	},
+}
+
+type nodeReadyModel struct {
+	NodeID    types.String `tfsdk:"node_id"`
+	Status    types.String `tfsdk:"status"`
+	Timeout   types.String `tfsdk:"timeout"`
+	Token     types.String `tfsdk:"token"`
+	ReachedAt types.String `tfsdk:"reached_at"`
+}
+
+type NodeReadyResource struct {
+	client *Client
+}
+
+func NewNodeReadyResource() resource.Resource {
+	return &NodeReadyResource{}
+}
+
+func (r *NodeReadyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_node_ready"
+}
+
+func (r *NodeReadyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = nodeReadySchema
+}
+
+func (r *NodeReadyResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+}
+
+func (r *NodeReadyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan nodeReadyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.clientFor(plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The validator already rejected invalid durations.
+	timeout, _ := time.ParseDuration(plan.Timeout.ValueString())
+	_, err := client.WaitNodeStatus(ctx, plan.NodeID.ValueString(), plan.Status.ValueString(), timeout)
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	plan.ReachedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &plan)...,
+	)
+}
+
+// Read only checks that the node still exists, the wait already happened and
+// a later status change doesn't undo it.
+func (r *NodeReadyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state nodeReadyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.clientFor(state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := client.ReadNode(ctx, state.NodeID.ValueString())
+	if errors.Is(err, ErrNodeNotFound) {
+		resp.State.RemoveResource(ctx)
+
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+	}
+}
+
+// Update only happens when timeout changes, which doesn't matter once the
+// node is ready.
+func (r *NodeReadyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan nodeReadyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &plan)...,
+	)
+}
+
+// clientFor returns the client reading the node of m, which uses the token of
+// m if it has one, like voltage_node does.
+func (r *NodeReadyResource) clientFor(m nodeReadyModel) (*Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	client, err := r.client.derive(clientOverrides{token: m.Token.ValueString()})
+	if err != nil {
+		diags.AddAttributeError(path.Root("token"), "Could not use the node token", err.Error())
+	}
+
+	return client, diags
+}
+
+// Delete only forgets the wait, the node is managed by voltage_node.
+func (r *NodeReadyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+func TestNodeReadyResourceToken(t *testing.T) {
+	p := newTestProvider(t, nil)
+	node, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, node).NodeID.ValueString()
+
+	// The node belongs to another account than the provider token.
+	requireNoErrors(t, p.configure(map[string]any{"token": "other-token", "poll_interval": "1ms"}))
+	config := map[string]any{"node_id": nodeID, "status": "running"}
+	_, diags = p.apply("voltage_node_ready", p.config("voltage_node_ready", nil), config)
+	if !hasErrors(diags) {
+		t.Fatal("waiting with the provider token succeeded")
+	}
+
+	config["token"] = testToken
+	state, diags := p.apply("voltage_node_ready", p.config("voltage_node_ready", nil), config)
+	requireNoErrors(t, diags)
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if attrs["reached_at"].IsNull() {
+		t.Error("reached_at wasn't set")
+	}
+
+	_, diags = p.read("voltage_node_ready", state)
+	requireNoErrors(t, diags)
+}
+
+func TestNodeReadyResourceWaits(t *testing.T) {
+	p := newTestProvider(t, map[string]any{"poll_interval": "1ms"})
+	config := testNodeConfig(map[string]any{"wait_for_ready": false})
+	node, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, node).NodeID.ValueString()
+
+	// New nodes are listed waiting_init before running.
+	lists := p.api.Calls("/node")
+	state, diags := p.apply("voltage_node_ready", p.config("voltage_node_ready", nil), map[string]any{
+		"node_id": nodeID,
+		"status":  "running",
+	})
+	requireNoErrors(t, diags)
+	if got := p.api.Calls("/node") - lists; got < 2 {
+		t.Errorf("called /node %d times, want to poll until the node runs", got)
+	}
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if attrs["reached_at"].IsNull() {
+		t.Error("reached_at wasn't set")
+	}
+	if remote, _ := p.api.Node(nodeID); *remote.Status != "running" {
+		t.Errorf("node status = %s, want running", *remote.Status)
+	}
+
+	// A node that never gets there times out.
+	p.api.UpdateNode(nodeID, func(n *voltage.NodeDocument) {
+		n.Status = toPtr("stopped")
+	})
+	_, diags = p.apply("voltage_node_ready", p.config("voltage_node_ready", nil), map[string]any{
+		"node_id": nodeID,
+		"status":  "running",
+		"timeout": "20ms",
+	})
+	if !hasErrors(diags) {
+		t.Error("waiting for a stopped node to run succeeded")
+	}
+}
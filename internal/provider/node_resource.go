@@ -2,30 +2,321 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	dschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
 )
 
+// The values accepted by Voltage when creating a node.
+var (
+	nodeNetworks       = []string{"mainnet", "testnet"}
+	nodePurchasedTypes = []string{"trial", "paid", "ondemand"}
+	nodeTypes          = []string{"standard", "lite"}
+)
+
+// maxFeeRate and maxBaseFee bound the channel fees, higher values are most
+// likely a mistake that would make the node useless for routing.
+const (
+	maxFeeRate = 100_000    // ppm, i.e. 10%.
+	maxBaseFee = 10_000_000 // msat, i.e. 10000 sats.
+)
+
+var lndVersionRegexp = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[\w.]+)?$`)
+
+// nodeSettingsSchemaAttributes are the attributes of the node settings.
+var nodeSettingsSchemaAttributes = map[string]schema.Attribute{
+	// Required fields.
+	"autopilot": schema.BoolAttribute{
+		Description: "When enabled, LND will turn on its autopilot feature",
+		Required:    true,
+	},
+	"grpc": schema.BoolAttribute{
+		Description: "When enabled, LND will active the gRPC API",
+		Required:    true,
+	},
+	"rest": schema.BoolAttribute{
+		Description: "When enabled, LND will active the REST API",
+		Required:    true,
+	},
+	"keysend": schema.BoolAttribute{
+		Description: "When enabled, LND will enable the Keysend feature",
+		Required:    true,
+	},
+	"whitelist": schema.ListAttribute{
+		Description: "A list of IPs that are allowed to talk to your node",
+		Required:    true,
+		ElementType: types.StringType,
+	},
+	"alias": schema.StringAttribute{
+		Description: "Your node's Alias on the peer to peer network",
+		Required:    true,
+	},
+	"color": schema.StringAttribute{
+		Description: "Your node's Color on the peer to peer network",
+		Required:    true,
+	},
+
+	// Optional fields.
+	// Optional booleans, maxpendingchannels and numgraphsyncpeers
+	// default to the values Voltage's API reference shows for a node
+	// with default settings, so their value is always explicit in
+	// state. It shows the other string settings empty, so they have
+	// no default and are left to Voltage when null.
+	// That makes amp and autocompaction on and the other booleans
+	// off. An omitted boolean is sent with its default, like an
+	// explicit one. Settings without a default, like webhook, are
+	// left out of the request when null.
+	"wumbo": schema.BoolAttribute{
+		Description: "When enabled, LND will accept Wumbo channels. Defaults to false",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"webhook": schema.StringAttribute{
+		Description: "Your webhook endpoint if you wish to receive webhook events",
+		Optional:    true,
+		Validators: []validator.String{
+			webhookURLValidator{},
+		},
+	},
+	"webhook_secret": schema.StringAttribute{
+		Description: "Webhook secret used to validate the webhook is coming from us",
+		Optional:    true,
+		Sensitive:   true,
+		Validators: []validator.String{
+			stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("webhook")),
+		},
+	},
+	"minchansize": schema.StringAttribute{
+		Description: "The minimum channel size your node will accept, in sats",
+		Optional:    true,
+	},
+	"maxchansize": schema.StringAttribute{
+		Description: "The maximum channel size your node will accept",
+		Optional:    true,
+	},
+	"autocompaction": schema.BoolAttribute{
+		Description: "When enabled, LND will automatically compact the databases on startup. Defaults to true",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(true),
+	},
+	"defaultfeerate": schema.StringAttribute{
+		Description: "Your default fee rate for your channels, in ppm. Can't be over 100000 (10%)",
+		Optional:    true,
+		Validators: []validator.String{
+			intStringValidator{min: 0, max: maxFeeRate},
+		},
+	},
+	"basefee": schema.StringAttribute{
+		Description: "Your base fee rate for your channels, in msat. Can't be over 10000000 (10000 sats)",
+		Optional:    true,
+		Validators: []validator.String{
+			intStringValidator{min: 0, max: maxBaseFee},
+		},
+	},
+	"amp": schema.BoolAttribute{
+		Description: "Enables AMP. Defaults to true",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(true),
+	},
+	"wtclient": schema.BoolAttribute{
+		Description: "Enables the watchtower client. Defaults to false",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"maxpendingchannels": schema.StringAttribute{
+		Description: "Maximum number of pending channels allowed for a single peer. Defaults to 2",
+		Optional:    true,
+		Computed:    true,
+		Default:     stringdefault.StaticString("2"),
+	},
+	"allowcircularroute": schema.BoolAttribute{
+		Description: "If enabled, allows a payment to exit and enter the same channel. Defaults to false",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"numgraphsyncpeers": schema.StringAttribute{
+		Description: "Number of peers used for syncing the graph. Defaults to 3",
+		Optional:    true,
+		Computed:    true,
+		Default:     stringdefault.StaticString("3"),
+	},
+	"gccanceledinvoicesonstartup": schema.BoolAttribute{
+		Description: "If enabled, deletes cancelled invoices only when LND starts up. Defaults to false",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"gccanceledinvoicesonthefly": schema.BoolAttribute{
+		Description: "If enabled, deletes cancelled invoices while LND is running. Defaults to false",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"torskipproxyforclearnettargets": schema.BoolAttribute{
+		Description: "Optimization for clearnet peers. See LND Docs. Defaults to false.",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"rpcmiddleware": schema.BoolAttribute{
+		Description: "Enables the rpcmiddleware, which can interecept certain rpc calls. See LND Docs. Defaults to false.",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"optionscidalias": schema.BoolAttribute{
+		Description: "If enabled, and optionscidalias is also enabled, it is possible to create zeroconf channels. See lnd docs. Defaults to false.",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+	"zeroconf": schema.BoolAttribute{
+		Description: "If enabled, and zeroconf is also enabled, it is possible to create zeroconf channels. See lnd docs. Defaults to false.",
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	},
+}
+
+// settingsAttributes returns nodeSettingsSchemaAttributes for the other
+// schemas describing node settings: computed, or configurable with the same
+// validators. Either way they don't have the defaults of voltage_node. A is
+// the Attribute type of the target schema package, which the data source
+// attribute types returned implement whatever the package.
+func settingsAttributes[A schema.Attribute](computed bool) map[string]A {
+	attrs := make(map[string]A, len(nodeSettingsSchemaAttributes))
+	for name, a := range nodeSettingsSchemaAttributes {
+		var conv dschema.Attribute
+		switch a := a.(type) {
+		case schema.BoolAttribute:
+			attr := dschema.BoolAttribute{Description: a.Description, Sensitive: a.Sensitive, Computed: computed}
+			if !computed {
+				attr.Required, attr.Optional, attr.Validators = a.Required, a.Optional, a.Validators
+			}
+			conv = attr
+		case schema.StringAttribute:
+			attr := dschema.StringAttribute{Description: a.Description, Sensitive: a.Sensitive, Computed: computed}
+			if !computed {
+				attr.Required, attr.Optional, attr.Validators = a.Required, a.Optional, a.Validators
+			}
+			conv = attr
+		case schema.ListAttribute:
+			attr := dschema.ListAttribute{
+				Description: a.Description, Sensitive: a.Sensitive, Computed: computed, ElementType: a.ElementType,
+			}
+			if !computed {
+				attr.Required, attr.Optional, attr.Validators = a.Required, a.Optional, a.Validators
+			}
+			conv = attr
+		}
+		attrs[name] = conv.(A)
+	}
+
+	return attrs
+}
+
 var nodeSchemaV1 = schema.Schema{
 	Description: "Creates and manage a node in Voltage",
 	Version:     1,
 	Attributes: map[string]schema.Attribute{
+		// The attributes that never change once the node exists keep their
+		// value in plans instead of showing as known after apply.
 		"node_id": schema.StringAttribute{
 			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
 		},
 		// "owner_id": schema.StringAttribute{
 		// 	Computed: true,
 		// },
 		"created": schema.StringAttribute{
 			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"status": schema.StringAttribute{
+			Description: "Status of the node",
+			Computed:    true,
+		},
+		"api_endpoint": schema.StringAttribute{
+			Description: "API Endpoint for the node",
+			Computed:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"raw_json": schema.StringAttribute{
+			Description: "The node as returned by the API, with secrets scrubbed. Only set when the provider " +
+				"expose_raw_response is enabled, meant for bug reports.",
+			Computed: true,
+		},
+		"is_mainnet": schema.BoolAttribute{
+			Description: "Whether the node runs on mainnet",
+			Computed:    true,
+			PlanModifiers: []planmodifier.Bool{
+				boolplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"expires_at": schema.StringAttribute{
+			Description: "Date that the node expires, in RFC3339 format. Only set for trial nodes",
+			Computed:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"lnd_version": schema.StringAttribute{
+			Description: "LND version the node should run. Voltage always creates nodes with its current release and " +
+				"can only upgrade them to its latest one, so bumping this value upgrades the node in place.",
+			Optional: true,
+			Validators: []validator.String{
+				stringvalidator.RegexMatches(lndVersionRegexp, "must be an LND version such as v0.16.4-beta"),
+			},
+		},
+		"init_duration_seconds": schema.Int64Attribute{
+			Description: "How long the node took to reach wait_for_status after being created, in seconds. " +
+				"Null when the creation didn't wait for it or the node was adopted or imported.",
+			Computed: true,
+			PlanModifiers: []planmodifier.Int64{
+				int64planmodifier.UseStateForUnknown(),
+			},
+		},
+		"running_lnd_version": schema.StringAttribute{
+			Description: "Version of LND the node is running",
+			Computed:    true,
 		},
 		// "user_ip": schema.StringAttribute{
 		// 	Computed: true,
@@ -34,141 +325,121 @@ var nodeSchemaV1 = schema.Schema{
 			Description: "Network the node is running on. Can be either 'testnet' or 'mainnet'.",
 			Required:    true,
 			Validators: []validator.String{
-				stringvalidator.OneOf("mainnet", "testnet"),
+				stringvalidator.OneOf(nodeNetworks...),
 			},
 		},
 		"purchased_type": schema.StringAttribute{
 			Description: "Purchase type of the node. Can be either 'trial', 'paid', or 'ondemand'.",
 			Required:    true,
 			Validators: []validator.String{
-				stringvalidator.OneOf("trial", "paid", "ondemand"),
+				stringvalidator.OneOf(nodePurchasedTypes...),
 			},
 		},
 		"type": schema.StringAttribute{
 			Description: "Type of node, either 'standard' or 'lite'",
 			Required:    true,
 			Validators: []validator.String{
-				stringvalidator.OneOf("standard", "lite"),
+				stringvalidator.OneOf(nodeTypes...),
 			},
 		},
 		"name": schema.StringAttribute{
 			Description: "User defined node name given at creation",
 			Required:    true,
 		},
+		"tags": schema.MapAttribute{
+			Description: "Tags to organize nodes. Voltage doesn't store them, they only live in the Terraform state.",
+			Optional:    true,
+			ElementType: types.StringType,
+		},
+		"tags_all": schema.MapAttribute{
+			Description: "Tags of the node merged with the provider default_tags, the node ones take precedence",
+			Computed:    true,
+			ElementType: types.StringType,
+		},
+		"token": schema.StringAttribute{
+			Description: "API token used to manage this node instead of the provider one, for nodes owned by " +
+				"another account. Nodes are imported with the provider token.",
+			Optional:  true,
+			Sensitive: true,
+		},
+		"poll_interval": schema.StringAttribute{
+			Description: "How long to wait before the first status check of this node, such as 10s. Later " +
+				"checks are spaced out more and more. Defaults to the provider one.",
+			Optional: true,
+			Validators: []validator.String{
+				durationValidator{},
+			},
+		},
+		"max_poll_attempts": schema.Int64Attribute{
+			Description: "Maximum number of status checks made while waiting for this node. " +
+				"Defaults to the provider max_poll_attempts.",
+			Optional: true,
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
+			},
+		},
+		"max_retries": schema.Int64Attribute{
+			Description: "How many times the API calls managing this node are sent again when they fail " +
+				"transiently. Defaults to the provider max_retries.",
+			Optional: true,
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
+			},
+		},
+		"wait_for_status": schema.StringAttribute{
+			Description: "Status the node must reach before the creation completes. 'waiting_init' (default) means " +
+				"the node is provisioned and its wallet needs to be initialized, 'waiting_unlock' that the wallet is " +
+				"initialized but locked and 'running' that LND is ready to use. The provider doesn't initialize nor " +
+				"unlock wallets, so the later statuses are only reached if that is done outside of Terraform.",
+			Optional: true,
+			Computed: true,
+			Default:  stringdefault.StaticString("waiting_init"),
+			Validators: []validator.String{
+				stringvalidator.OneOf("waiting_init", "waiting_unlock", "running"),
+			},
+		},
+		"wait_for_ready": schema.BoolAttribute{
+			Description: "When disabled, the creation completes as soon as Voltage accepts it instead of waiting for " +
+				"wait_for_status. Computed attributes then reflect the just created node, e.g. api_endpoint may be " +
+				"empty, and are refreshed by later reads. Defaults to true",
+			Optional: true,
+			Computed: true,
+			Default:  booldefault.StaticBool(true),
+		},
+		"reconcile": schema.StringAttribute{
+			Description: "What to do when settings that can't be updated in place differ from the configuration, " +
+				"whether they changed outside of Terraform or in the configuration. 'warn' (default) warns on refresh " +
+				"and fails on apply, 'replace' plans to recreate the node.",
+			Optional: true,
+			Validators: []validator.String{
+				stringvalidator.OneOf("warn", "replace"),
+			},
+		},
+		"adopt_existing": schema.BoolAttribute{
+			Description: "When enabled, creating the resource adopts an existing node with the same name and network " +
+				"instead of creating a new one. Useful to recover from a lost state without paying for a duplicate node.",
+			Optional: true,
+		},
+		"extra_create_params": schema.MapAttribute{
+			Description: "Additional fields sent in the node creation request, as JSON values, e.g. " +
+				"{ new_param = jsonencode(true) }. Lets new Voltage parameters be used before the provider supports " +
+				"them. Fields the provider already sets take precedence. Only used on creation.",
+			Optional:    true,
+			ElementType: types.StringType,
+			Validators: []validator.Map{
+				jsonValuesValidator{},
+			},
+		},
 		"settings": schema.SingleNestedAttribute{
 			Description: "Settings for the Lightning Node",
 			Required:    true,
-			Attributes: map[string]schema.Attribute{
-				// Required fields.
-				"autopilot": schema.BoolAttribute{
-					Description: "When enabled, LND will turn on its autopilot feature",
-					Required:    true,
-				},
-				"grpc": schema.BoolAttribute{
-					Description: "When enabled, LND will active the gRPC API",
-					Required:    true,
-				},
-				"rest": schema.BoolAttribute{
-					Description: "When enabled, LND will active the REST API",
-					Required:    true,
-				},
-				"keysend": schema.BoolAttribute{
-					Description: "When enabled, LND will enable the Keysend feature",
-					Required:    true,
-				},
-				"whitelist": schema.ListAttribute{
-					Description: "A list of IPs that are allowed to talk to your node",
-					Required:    true,
-					ElementType: types.StringType,
-				},
-				"alias": schema.StringAttribute{
-					Description: "Your node's Alias on the peer to peer network",
-					Required:    true,
-				},
-				"color": schema.StringAttribute{
-					Description: "Your node's Color on the peer to peer network",
-					Required:    true,
-				},
-
-				// Optional fields.
-				"wumbo": schema.BoolAttribute{
-					Description: "When enabled, LND will accept Wumbo channels",
-					Optional:    true,
-				},
-				"webhook": schema.StringAttribute{
-					Description: "Your webhook endpoint if you wish to receive webhook events",
-					Optional:    true,
-				},
-				"webhook_secret": schema.StringAttribute{
-					Description: "Webhook secret used to validate the webhook is coming from us",
-					Optional:    true,
-					Sensitive:   true,
-				},
-				"minchansize": schema.StringAttribute{
-					Description: "The minimum channel size your node will accept",
-					Optional:    true,
-				},
-				"maxchansize": schema.StringAttribute{
-					Description: "The maximum channel size your node will accept",
-					Optional:    true,
-				},
-				"autocompaction": schema.BoolAttribute{
-					Description: "When enabled, LND will automatically compact the databases on startup",
-					Optional:    true,
-				},
-				"defaultfeerate": schema.StringAttribute{
-					Description: "Your default fee rate for your channels",
-					Optional:    true,
-				},
-				"basefee": schema.StringAttribute{
-					Description: "Your base fee rate for your channels",
-					Optional:    true,
-				},
-				"amp": schema.BoolAttribute{
-					Description: "Enables AMP",
-					Optional:    true,
-				},
-				"wtclient": schema.BoolAttribute{
-					Description: "Enables the watchtower client",
-					Optional:    true,
-				},
-				"maxpendingchannels": schema.StringAttribute{
-					Description: "Maximum number of pending channels allowed for a single peer",
-					Optional:    true,
-				},
-				"allowcircularroute": schema.BoolAttribute{
-					Description: "If enabled, allows a payment to exit and enter the same channel",
-					Optional:    true,
-				},
-				"numgraphsyncpeers": schema.StringAttribute{
-					Description: "Number of peers used for syncing the graph",
-					Optional:    true,
-				},
-				"gccanceledinvoicesonstartup": schema.BoolAttribute{
-					Description: "If enabled, deletes cancelled invoices only when LND starts up",
-					Optional:    true,
-				},
-				"gccanceledinvoicesonthefly": schema.BoolAttribute{
-					Description: "If enabled, deletes cancelled invoices while LND is running",
-					Optional:    true,
-				},
-				"torskipproxyforclearnettargets": schema.BoolAttribute{
-					Description: "Optimization for clearnet peers. See LND Docs.",
-					Optional:    true,
-				},
-				"rpcmiddleware": schema.BoolAttribute{
-					Description: "Enables the rpcmiddleware, which can interecept certain rpc calls. See LND Docs.",
-					Optional:    true,
-				},
-				"optionscidalias": schema.BoolAttribute{
-					Description: "If enabled, and optionscidalias is also enabled, it is possible to create zeroconf channels. See lnd docs.",
-					Optional:    true,
-				},
-				"zeroconf": schema.BoolAttribute{
-					Description: "If enabled, and zeroconf is also enabled, it is possible to create zeroconf channels. See lnd docs.",
-					Optional:    true,
-				},
-			},
+			Attributes:  nodeSettingsSchemaAttributes,
+		},
+		"effective_settings": schema.SingleNestedAttribute{
+			Description: "Settings the node runs with as reported by Voltage, refreshed on every read. They may differ " +
+				"from settings when Voltage normalizes them or they were changed outside of Terraform.",
+			Computed:   true,
+			Attributes: settingsAttributes[schema.Attribute](true),
 		},
 	},
 }
@@ -176,44 +447,397 @@ var nodeSchemaV1 = schema.Schema{
 type nodeModel struct {
 	NodeID types.String `tfsdk:"node_id"`
 	// OwnerID       types.String `tfsdk:"owner_id"`
-	Created types.String `tfsdk:"created"`
+	Created           types.String `tfsdk:"created"`
+	Status            types.String `tfsdk:"status"`
+	APIEndpoint       types.String `tfsdk:"api_endpoint"`
+	RawJSON           types.String `tfsdk:"raw_json"`
+	IsMainnet         types.Bool   `tfsdk:"is_mainnet"`
+	ExpiresAt         types.String `tfsdk:"expires_at"`
+	LndVersion        types.String `tfsdk:"lnd_version"`
+	RunningLndVersion types.String `tfsdk:"running_lnd_version"`
+	InitDuration      types.Int64  `tfsdk:"init_duration_seconds"`
 	// UserIP        types.String `tfsdk:"user_ip"`
-	Network       types.String `tfsdk:"network"`
-	PurchasedType types.String `tfsdk:"purchased_type"`
-	Type          types.String `tfsdk:"type"`
-	Name          types.String `tfsdk:"name"`
-	Settings      struct {
-		AutoPilot                      types.Bool     `tfsdk:"autopilot"`
-		Grpc                           types.Bool     `tfsdk:"grpc"`
-		Rest                           types.Bool     `tfsdk:"rest"`
-		Keysend                        types.Bool     `tfsdk:"keysend"`
-		Whitelist                      []types.String `tfsdk:"whitelist"`
-		Alias                          types.String   `tfsdk:"alias"`
-		Color                          types.String   `tfsdk:"color"`
-		Wumbo                          types.Bool     `tfsdk:"wumbo"`
-		Webhook                        types.String   `tfsdk:"webhook"`
-		WebhookSecret                  types.String   `tfsdk:"webhook_secret"`
-		MinChanSize                    types.String   `tfsdk:"minchansize"`
-		MaxChanSize                    types.String   `tfsdk:"maxchansize"`
-		AutoCompactation               types.Bool     `tfsdk:"autocompaction"`
-		DefaultFeeRate                 types.String   `tfsdk:"defaultfeerate"`
-		BaseFee                        types.String   `tfsdk:"basefee"`
-		Amp                            types.Bool     `tfsdk:"amp"`
-		WtClient                       types.Bool     `tfsdk:"wtclient"`
-		MaxPendingChannels             types.String   `tfsdk:"maxpendingchannels"`
-		AllowCircularRoute             types.Bool     `tfsdk:"allowcircularroute"`
-		NumGraphSyncPeers              types.String   `tfsdk:"numgraphsyncpeers"`
-		GCCanceledInvoicesOnStartUp    types.Bool     `tfsdk:"gccanceledinvoicesonstartup"`
-		GCCanceledInvoicesOnTheFly     types.Bool     `tfsdk:"gccanceledinvoicesonthefly"`
-		TorSkipProxyForClearnetTargets types.Bool     `tfsdk:"torskipproxyforclearnettargets"`
-		RPCMiddleware                  types.Bool     `tfsdk:"rpcmiddleware"`
-		OptionSCIDAlias                types.Bool     `tfsdk:"optionscidalias"`
-		ZeroConf                       types.Bool     `tfsdk:"zeroconf"`
-	} `tfsdk:"settings"`
+	Network         types.String `tfsdk:"network"`
+	PurchasedType   types.String `tfsdk:"purchased_type"`
+	Type            types.String `tfsdk:"type"`
+	Name            types.String `tfsdk:"name"`
+	Tags            types.Map    `tfsdk:"tags"`
+	TagsAll         types.Map    `tfsdk:"tags_all"`
+	Token           types.String `tfsdk:"token"`
+	PollInterval    types.String `tfsdk:"poll_interval"`
+	MaxPollAttempts types.Int64  `tfsdk:"max_poll_attempts"`
+	MaxRetries      types.Int64  `tfsdk:"max_retries"`
+	WaitForStatus   types.String `tfsdk:"wait_for_status"`
+	WaitForReady    types.Bool   `tfsdk:"wait_for_ready"`
+	AdoptExisting   types.Bool   `tfsdk:"adopt_existing"`
+	Reconcile       types.String `tfsdk:"reconcile"`
+	ExtraParams     types.Map    `tfsdk:"extra_create_params"`
+	// Settings is only nil in the state of a node whose creation failed
+	// after it was recorded, see Create.
+	Settings  *nodeSettingsModel `tfsdk:"settings"`
+	Effective types.Object       `tfsdk:"effective_settings"`
+}
+
+// nodeSettingsModel maps voltage.NodeSettings, it is shared by the node
+// resource and data source.
+type nodeSettingsModel struct {
+	AutoPilot                      types.Bool     `tfsdk:"autopilot"`
+	Grpc                           types.Bool     `tfsdk:"grpc"`
+	Rest                           types.Bool     `tfsdk:"rest"`
+	Keysend                        types.Bool     `tfsdk:"keysend"`
+	Whitelist                      []types.String `tfsdk:"whitelist"`
+	Alias                          types.String   `tfsdk:"alias"`
+	Color                          types.String   `tfsdk:"color"`
+	Wumbo                          types.Bool     `tfsdk:"wumbo"`
+	Webhook                        types.String   `tfsdk:"webhook"`
+	WebhookSecret                  types.String   `tfsdk:"webhook_secret"`
+	MinChanSize                    types.String   `tfsdk:"minchansize"`
+	MaxChanSize                    types.String   `tfsdk:"maxchansize"`
+	AutoCompactation               types.Bool     `tfsdk:"autocompaction"`
+	DefaultFeeRate                 types.String   `tfsdk:"defaultfeerate"`
+	BaseFee                        types.String   `tfsdk:"basefee"`
+	Amp                            types.Bool     `tfsdk:"amp"`
+	WtClient                       types.Bool     `tfsdk:"wtclient"`
+	MaxPendingChannels             types.String   `tfsdk:"maxpendingchannels"`
+	AllowCircularRoute             types.Bool     `tfsdk:"allowcircularroute"`
+	NumGraphSyncPeers              types.String   `tfsdk:"numgraphsyncpeers"`
+	GCCanceledInvoicesOnStartUp    types.Bool     `tfsdk:"gccanceledinvoicesonstartup"`
+	GCCanceledInvoicesOnTheFly     types.Bool     `tfsdk:"gccanceledinvoicesonthefly"`
+	TorSkipProxyForClearnetTargets types.Bool     `tfsdk:"torskipproxyforclearnettargets"`
+	RPCMiddleware                  types.Bool     `tfsdk:"rpcmiddleware"`
+	OptionSCIDAlias                types.Bool     `tfsdk:"optionscidalias"`
+	ZeroConf                       types.Bool     `tfsdk:"zeroconf"`
+}
+
+// toAPI returns the settings to send to the API.
+func (s nodeSettingsModel) toAPI() (voltage.NodeSettings, error) {
+	whitelist, err := whitelistIPs(s.Whitelist)
+	if err != nil {
+		return voltage.NodeSettings{}, err
+	}
+
+	return voltage.NodeSettings{
+		Autopilot:                      s.AutoPilot.ValueBoolPointer(),
+		Grpc:                           s.Grpc.ValueBoolPointer(),
+		Rest:                           s.Rest.ValueBoolPointer(),
+		Keysend:                        s.Keysend.ValueBoolPointer(),
+		Whitelist:                      toPtr(whitelist),
+		Alias:                          s.Alias.ValueStringPointer(),
+		Color:                          s.Color.ValueStringPointer(),
+		Wumbo:                          s.Wumbo.ValueBoolPointer(),
+		Webhook:                        optionalString(s.Webhook),
+		WebhookSecret:                  optionalString(s.WebhookSecret),
+		Minchansize:                    optionalString(s.MinChanSize),
+		Maxchansize:                    optionalString(s.MaxChanSize),
+		Autocompaction:                 s.AutoCompactation.ValueBoolPointer(),
+		Defaultfeerate:                 optionalString(s.DefaultFeeRate),
+		Basefee:                        optionalString(s.BaseFee),
+		Amp:                            s.Amp.ValueBoolPointer(),
+		Wtclient:                       s.WtClient.ValueBoolPointer(),
+		Maxpendingchannels:             optionalString(s.MaxPendingChannels),
+		Allowcircularroute:             s.AllowCircularRoute.ValueBoolPointer(),
+		Numgraphsyncpeers:              optionalString(s.NumGraphSyncPeers),
+		Gccanceledinvoicesonstartup:    s.GCCanceledInvoicesOnStartUp.ValueBoolPointer(),
+		Gccanceledinvoicesonthefly:     s.GCCanceledInvoicesOnTheFly.ValueBoolPointer(),
+		Torskipproxyforclearnettargets: s.TorSkipProxyForClearnetTargets.ValueBoolPointer(),
+		Rpcmiddleware:                  s.RPCMiddleware.ValueBoolPointer(),
+		Optionscidalias:                s.OptionSCIDAlias.ValueBoolPointer(),
+		Zeroconf:                       s.ZeroConf.ValueBoolPointer(),
+	}, nil
+}
+
+// whitelistIPs returns the normalized entries of whitelist. It fails on null
+// entries, which would otherwise be sent as empty strings.
+func whitelistIPs(whitelist []types.String) ([]string, error) {
+	ips, err := eachErr(whitelist, func(w types.String) (string, error) {
+		if w.IsNull() || w.IsUnknown() {
+			return "", errors.New("whitelist entries can't be null")
+		}
+
+		return w.ValueString(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeWhitelist(ips), nil
+}
+
+// setFromAPI sets s from the settings returned by the API.
+func (s *nodeSettingsModel) setFromAPI(v *voltage.NodeSettings) {
+	s.AutoPilot = types.BoolPointerValue(v.Autopilot)
+	s.Grpc = types.BoolPointerValue(v.Grpc)
+	s.Rest = types.BoolPointerValue(v.Rest)
+	s.Keysend = types.BoolPointerValue(v.Keysend)
+	s.Alias = types.StringPointerValue(v.Alias)
+	s.Color = types.StringPointerValue(v.Color)
+	s.Wumbo = types.BoolPointerValue(v.Wumbo)
+	s.Webhook = stringSetting(v.Webhook)
+	s.WebhookSecret = stringSetting(v.WebhookSecret)
+	s.MinChanSize = stringSetting(v.Minchansize)
+	s.MaxChanSize = stringSetting(v.Maxchansize)
+	s.AutoCompactation = types.BoolPointerValue(v.Autocompaction)
+	s.DefaultFeeRate = stringSetting(v.Defaultfeerate)
+	s.BaseFee = stringSetting(v.Basefee)
+	s.Amp = types.BoolPointerValue(v.Amp)
+	s.WtClient = types.BoolPointerValue(v.Wtclient)
+	s.MaxPendingChannels = stringSetting(v.Maxpendingchannels)
+	s.AllowCircularRoute = types.BoolPointerValue(v.Allowcircularroute)
+	s.NumGraphSyncPeers = stringSetting(v.Numgraphsyncpeers)
+	s.GCCanceledInvoicesOnStartUp = types.BoolPointerValue(v.Gccanceledinvoicesonstartup)
+	s.GCCanceledInvoicesOnTheFly = types.BoolPointerValue(v.Gccanceledinvoicesonthefly)
+	s.TorSkipProxyForClearnetTargets = types.BoolPointerValue(v.Torskipproxyforclearnettargets)
+	s.RPCMiddleware = types.BoolPointerValue(v.Rpcmiddleware)
+	s.OptionSCIDAlias = types.BoolPointerValue(v.Optionscidalias)
+	s.ZeroConf = types.BoolPointerValue(v.Zeroconf)
+	s.Whitelist = nil
+	if v.Whitelist != nil {
+		s.Whitelist = each(normalizeWhitelist(*v.Whitelist), types.StringValue)
+	}
+}
+
+// setDefaults sets the settings that are still null to their schema default.
+// Voltage omits some of them, or reports them as empty strings which
+// setFromAPI makes null, and leaving them null would make a config that also
+// omits them plan a change after an import.
+func (s *nodeSettingsModel) setDefaults(ctx context.Context) {
+	attrs := nodeSettingsSchemaAttributes
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if val, ok := field.Interface().(attr.Value); !ok || !val.IsNull() {
+			continue
+		}
+
+		switch a := attrs[v.Type().Field(i).Tag.Get("tfsdk")].(type) {
+		case schema.BoolAttribute:
+			if a.Default != nil {
+				var resp defaults.BoolResponse
+				a.Default.DefaultBool(ctx, defaults.BoolRequest{}, &resp)
+				field.Set(reflect.ValueOf(resp.PlanValue))
+			}
+		case schema.StringAttribute:
+			if a.Default != nil {
+				var resp defaults.StringResponse
+				a.Default.DefaultString(ctx, defaults.StringRequest{}, &resp)
+				field.Set(reflect.ValueOf(resp.PlanValue))
+			}
+		}
+	}
+}
+
+// copyFrom sets the settings of s named in names, by their tfsdk name, to the
+// ones of remote.
+func (s *nodeSettingsModel) copyFrom(remote nodeSettingsModel, names []string) {
+	local, other := reflect.ValueOf(s).Elem(), reflect.ValueOf(remote)
+	for i := 0; i < local.NumField(); i++ {
+		if contains(names, local.Type().Field(i).Tag.Get("tfsdk")) {
+			local.Field(i).Set(other.Field(i))
+		}
+	}
+}
+
+// driftedSettings returns the tfsdk names of the settings that differ
+// between s and remote and can't be updated in place. Settings remote
+// doesn't report are ignored.
+func (s nodeSettingsModel) driftedSettings(remote nodeSettingsModel) []string {
+	local, other := reflect.ValueOf(s), reflect.ValueOf(remote)
+
+	var drifted []string
+	for i := 0; i < local.NumField(); i++ {
+		name := local.Type().Field(i).Tag.Get("tfsdk")
+		switch name {
+		case "whitelist", "alias", "color", "grpc", "rest", "keysend":
+			continue
+		}
+
+		l, ok := local.Field(i).Interface().(attr.Value)
+		if !ok {
+			continue
+		}
+		o := other.Field(i).Interface().(attr.Value)
+		if !o.IsNull() && !l.IsUnknown() && !l.Equal(o) {
+			drifted = append(drifted, name)
+		}
+	}
+
+	return drifted
+}
+
+// createRequest returns the request creating the node described by m.
+func (m nodeModel) createRequest() (voltage.PostNodeCreateJSONRequestBody, error) {
+	settings, err := m.Settings.toAPI()
+	if err != nil {
+		return voltage.PostNodeCreateJSONRequestBody{}, err
+	}
+
+	return voltage.PostNodeCreateJSONRequestBody{
+		Name:          m.Name.ValueString(),
+		Network:       m.Network.ValueString(),
+		PurchasedType: m.PurchasedType.ValueString(),
+		Type:          m.Type.ValueString(),
+		Settings:      settings,
+	}, nil
+}
+
+// extraCreateParams returns extra_create_params decoded as JSON values.
+func (m nodeModel) extraCreateParams(ctx context.Context) (map[string]json.RawMessage, diag.Diagnostics) {
+	var params map[string]string
+	if diags := m.ExtraParams.ElementsAs(ctx, &params, false); diags.HasError() {
+		return nil, diags
+	}
+
+	extra := make(map[string]json.RawMessage, len(params))
+	for k, v := range params {
+		extra[k] = json.RawMessage(v)
+	}
+
+	return extra, nil
+}
+
+// setComputed sets all the computed attributes from node.
+func (m *nodeModel) setComputed(node *voltage.NodeDocument) {
+	m.NodeID = types.StringPointerValue(node.NodeId)
+	m.Created = types.StringPointerValue(node.Created)
+	m.Status = types.StringPointerValue(node.Status)
+	m.APIEndpoint = types.StringPointerValue(node.ApiEndpoint)
+	m.IsMainnet = types.BoolValue(node.Network != nil && *node.Network == "mainnet")
+	m.RunningLndVersion = types.StringPointerValue(node.LndVersion)
+
+	m.ExpiresAt = types.StringNull()
+	if node.PurchasedType != nil && *node.PurchasedType == "trial" {
+		m.ExpiresAt = types.StringPointerValue(node.Expires)
+	}
+}
+
+// pendingComputed returns the computed attributes that Voltage only fills in
+// some time after the node is created and are still null in m. They are left
+// null rather than unknown, the next refresh populates them.
+func (m nodeModel) pendingComputed() []string {
+	var pending []string
+	if m.APIEndpoint.IsNull() {
+		pending = append(pending, "api_endpoint")
+	}
+	if m.RunningLndVersion.IsNull() {
+		pending = append(pending, "running_lnd_version")
+	}
+
+	return pending
+}
+
+// setEffectiveSettings sets effective_settings from the settings of node.
+func (m *nodeModel) setEffectiveSettings(ctx context.Context, node *voltage.NodeDocument) diag.Diagnostics {
+	attrTypes := nodeSchemaV1.Attributes["effective_settings"].GetType().(types.ObjectType).AttrTypes
+	if node.Settings == nil {
+		m.Effective = types.ObjectNull(attrTypes)
+
+		return nil
+	}
+
+	var s nodeSettingsModel
+	s.setFromAPI(node.Settings)
+
+	var diags diag.Diagnostics
+	m.Effective, diags = types.ObjectValueFrom(ctx, attrTypes, s)
+
+	return diags
+}
+
+// setRawJSON sets raw_json from node if enabled, otherwise it is null.
+func (m *nodeModel) setRawJSON(node *voltage.NodeDocument, enabled bool) {
+	m.RawJSON = types.StringNull()
+	if !enabled {
+		return
+	}
+
+	if b, err := json.Marshal(node); err == nil {
+		m.RawJSON = types.StringValue(scrubBody(b))
+	}
+}
+
+// setTagsAll merges defaults with the tags of m into its tags_all.
+func (m *nodeModel) setTagsAll(ctx context.Context, defaults map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.TagsAll, diags = mergeTags(ctx, m.Tags, defaults)
+
+	return diags
+}
+
+// mergeTags returns defaults with tags on top, or an unknown map if tags
+// aren't known yet.
+func mergeTags(ctx context.Context, tags types.Map, defaults map[string]string) (types.Map, diag.Diagnostics) {
+	if tags.IsUnknown() {
+		return types.MapUnknown(types.StringType), nil
+	}
+	for _, v := range tags.Elements() {
+		if v.IsUnknown() {
+			return types.MapUnknown(types.StringType), nil
+		}
+	}
+
+	all := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		all[k] = v
+	}
+
+	var values map[string]string
+	diags := tags.ElementsAs(ctx, &values, false)
+	for k, v := range values {
+		all[k] = v
+	}
+
+	merged, d := types.MapValueFrom(ctx, types.StringType, all)
+
+	return merged, append(diags, d...)
+}
+
+// immutable returns a copy of m without the computed attributes and the ones
+// that can be updated in place, so that comparing the result for a plan and
+// a state tells whether the node itself needs to change.
+func (m nodeModel) immutable() nodeModel {
+	m.NodeID = types.StringNull()
+	m.Created = types.StringNull()
+	m.Status = types.StringNull()
+	m.APIEndpoint = types.StringNull()
+	m.RawJSON = types.StringNull()
+	m.Effective = types.Object{}
+	m.InitDuration = types.Int64Null()
+	m.IsMainnet = types.BoolNull()
+	m.ExpiresAt = types.StringNull()
+	m.LndVersion = types.StringNull()
+	m.RunningLndVersion = types.StringNull()
+	m.Tags = types.MapNull(types.StringType)
+	m.TagsAll = types.MapNull(types.StringType)
+	m.WaitForStatus = types.StringNull()
+	m.WaitForReady = types.BoolNull()
+	m.AdoptExisting = types.BoolNull()
+	m.Reconcile = types.StringNull()
+	m.ExtraParams = types.MapNull(types.StringType)
+	m.Token = types.StringNull()
+	m.PollInterval = types.StringNull()
+	m.MaxPollAttempts = types.Int64Null()
+	m.MaxRetries = types.Int64Null()
+	if m.Settings != nil {
+		settings := *m.Settings
+		settings.Whitelist = nil
+		settings.Grpc = types.BoolNull()
+		settings.Rest = types.BoolNull()
+		settings.Keysend = types.BoolNull()
+		settings.Alias = types.StringNull()
+		settings.Color = types.StringNull()
+		m.Settings = &settings
+	}
+
+	return m
 }
 
 type NodeResource struct {
 	client *Client
+
+	// The provider settings for nodes, see providerData.
+	defaultTags       map[string]string
+	checkNodeNames    bool
+	exposeRawResponse bool
 }
 
 func NewNodeResource() resource.Resource {
@@ -228,22 +852,256 @@ func (r *NodeResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 	resp.Schema = nodeSchemaV1
 }
 
+// ValidateConfig checks the rules spanning several attributes.
+func (r *NodeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateSettingsConfig(ctx, req.Config, path.Root("settings"))...)
+
+	var waitForReady types.Bool
+	var waitForStatus types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("wait_for_ready"), &waitForReady)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("wait_for_status"), &waitForStatus)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !waitForReady.IsNull() && !waitForReady.IsUnknown() && !waitForReady.ValueBool() &&
+		!waitForStatus.IsNull() && !waitForStatus.IsUnknown() {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("wait_for_status"),
+			"wait_for_status is ignored",
+			"The node creation doesn't wait for any status when wait_for_ready is false.",
+		)
+	}
+
+	var extra types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("extra_create_params"), &extra)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	for k := range extra.Elements() {
+		if isCreateBodyField(k) {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("extra_create_params").AtMapKey(k),
+				"Extra create parameter is ignored",
+				fmt.Sprintf("%s is already set by the provider, use the corresponding attribute instead.", k),
+			)
+		}
+	}
+}
+
+// validateSettingsConfig checks the rules spanning several of the node
+// settings found at settings in config.
+func validateSettingsConfig(ctx context.Context, config tfsdk.Config, settings path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var zeroConf, scidAlias types.Bool
+	var minChanSize, maxChanSize types.String
+	var whitelist types.List
+	diags.Append(config.GetAttribute(ctx, settings.AtName("zeroconf"), &zeroConf)...)
+	diags.Append(config.GetAttribute(ctx, settings.AtName("optionscidalias"), &scidAlias)...)
+	diags.Append(config.GetAttribute(ctx, settings.AtName("minchansize"), &minChanSize)...)
+	diags.Append(config.GetAttribute(ctx, settings.AtName("maxchansize"), &maxChanSize)...)
+	diags.Append(config.GetAttribute(ctx, settings.AtName("whitelist"), &whitelist)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	// LND refuses to start with zero conf channels but no SCID aliases.
+	if zeroConf.ValueBool() && !scidAlias.IsUnknown() && !scidAlias.ValueBool() {
+		diags.AddAttributeError(
+			settings.AtName("zeroconf"),
+			"Invalid zeroconf setting",
+			"zeroconf requires optionscidalias to be enabled.",
+		)
+	}
+
+	minSize, minErr := strconv.ParseInt(minChanSize.ValueString(), 10, 64)
+	maxSize, maxErr := strconv.ParseInt(maxChanSize.ValueString(), 10, 64)
+	if minErr == nil && maxErr == nil && minSize > maxSize {
+		diags.AddAttributeError(
+			settings.AtName("maxchansize"),
+			"Invalid channel size limits",
+			fmt.Sprintf("maxchansize (%d) can't be lower than minchansize (%d).", maxSize, minSize),
+		)
+	}
+
+	// An empty whitelist may be on purpose, e.g. a node only used through
+	// the Voltage console, but it is easy to get by mistake.
+	if !whitelist.IsNull() && !whitelist.IsUnknown() && len(whitelist.Elements()) == 0 {
+		diags.AddAttributeWarning(
+			settings.AtName("whitelist"),
+			"Empty whitelist",
+			"Voltage only lets whitelisted IPs reach the node gRPC and REST APIs, so with an empty whitelist "+
+				"nobody can. List the IPs or CIDRs of your clients, or 0.0.0.0/0 to allow everybody.",
+		)
+	}
+
+	return diags
+}
+
+// ModifyPlan plans tags_all, replaces nodes whose settings can't be updated
+// in place when reconcile is "replace", and warns when the name of a new node
+// is already taken.
+func (r *NodeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	// Planned here rather than left unknown, so that changing only the
+	// provider default_tags shows up as a change to the node.
+	var tags types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("tags"), &tags)...)
+	tagsAll, diags := mergeTags(ctx, tags, r.defaultTags)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("tags_all"), tagsAll)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if req.State.Raw.IsNull() {
+		r.checkNodeName(ctx, req, resp)
+
+		return
+	}
+
+	var plan, state nodeModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Reconcile.ValueString() == "replace" &&
+		!reflect.DeepEqual(plan.immutable().Settings, state.immutable().Settings) {
+		resp.RequiresReplace.Append(path.Root("settings"))
+	}
+}
+
+// checkNodeName warns when the name of the node about to be created is
+// already taken. It only runs when the provider check_node_names is enabled,
+// as it costs an API call per planned node.
+func (r *NodeResource) checkNodeName(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || !r.checkNodeNames {
+		return
+	}
+
+	var name, network, token types.String
+	var adopt types.Bool
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("name"), &name)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("token"), &token)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("network"), &network)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("adopt_existing"), &adopt)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Adopting a node with the same name is the whole point of adopt_existing.
+	if name.IsUnknown() || network.IsUnknown() || token.IsUnknown() || adopt.ValueBool() {
+		return
+	}
+
+	client, err := r.client.derive(clientOverrides{token: token.ValueString()})
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("token"), "Could not use the node token", err.Error())
+
+		return
+	}
+
+	taken, err := client.NodeNameTaken(ctx, name.ValueString(), network.ValueString())
+	if err != nil {
+		tflog.Warn(ctx, "Could not check the node name", map[string]any{"error": err.Error()})
+
+		return
+	}
+
+	// Names can be reused once the node holding them is deleted, so this
+	// is only a warning.
+	if taken {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("name"),
+			"Node name already taken",
+			fmt.Sprintf("A node called %q already exists on %s, creating this node will likely fail. "+
+				"Use a different name, or set adopt_existing to manage the existing node.",
+				name.ValueString(), network.ValueString()),
+		)
+	}
+}
+
+// clientFor returns the client managing the node of m, which uses the token,
+// poll and retry settings of m if it has them.
+func (r *NodeResource) clientFor(m nodeModel) (*Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// The validator already rejected invalid durations.
+	pollInterval, _ := time.ParseDuration(m.PollInterval.ValueString())
+	client, err := r.client.derive(clientOverrides{
+		token:           m.Token.ValueString(),
+		pollInterval:    pollInterval,
+		maxPollAttempts: int(m.MaxPollAttempts.ValueInt64()),
+		maxRetries:      int(m.MaxRetries.ValueInt64()),
+	})
+	if err != nil {
+		diags.AddAttributeError(path.Root("token"), "Could not use the node token", err.Error())
+	}
+
+	return client, diags
+}
+
 func (r *NodeResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*voltage.ClientWithResponses)
+	data, ok := req.ProviderData.(*providerData)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected '*voltage.Client', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = NewClient(client)
+	r.client = data.client
+	r.defaultTags = data.defaultTags
+	r.checkNodeNames = data.checkNodeNames
+	r.exposeRawResponse = data.exposeRawResponse
+}
+
+// knownAPIErrors maps API error messages, matched case insensitively by
+// substring, to diagnostics explaining how to fix them. The API doesn't return
+// error codes, so the messages are the only thing to go by. Only messages
+// documented in the API reference are listed.
+var knownAPIErrors = []struct {
+	match   string
+	summary string
+	hint    string
+}{
+	{
+		match:   "node subscription is not active",
+		summary: "Node subscription is not active",
+		hint:    "The Voltage subscription paying for the node lapsed. Renew it in the Voltage dashboard, then retry.",
+	},
+	{
+		match:   "maxchansize is invalid",
+		summary: "Invalid maxchansize",
+		hint:    "Voltage rejected settings.maxchansize. Set it to a channel size in satoshis, or remove it.",
+	},
+	{
+		match:   "node is already up to date",
+		summary: "Node already up to date",
+		hint:    "Voltage offers no newer LND version for the node. Set lnd_version to the version it runs, or remove it.",
+	},
+	{
+		match:   "missing required field",
+		summary: "Missing required field",
+		hint:    "The request lacked a field Voltage requires. This is likely a provider bug, please report it.",
+	},
+	{
+		match:   "validation error",
+		summary: "Invalid request",
+		hint:    "Voltage rejected a value of the request, the message below names it.",
+	},
 }
 
 func errToDiags(err error) diag.Diagnostics {
@@ -255,9 +1113,36 @@ func errToDiags(err error) diag.Diagnostics {
 		diags   diag.Diagnostics
 		cErr    *ClientError
 		summary string
+		detail  = err.Error()
 	)
 
-	if errors.As(err, &cErr) {
+	isClientErr := errors.As(err, &cErr)
+	if isClientErr && cErr.method != "" {
+		detail += fmt.Sprintf("\n\nRequest: %s %s", cErr.method, cErr.path)
+	}
+
+	if isClientErr && isAuthError(cErr) {
+		diags.AddError(
+			"Authentication failed, check your Voltage API token",
+			"The Voltage API rejected the provided token. Set a valid token in the provider configuration "+
+				"or through the VOLTAGE_TOKEN environment variable.\n\n"+detail,
+		)
+
+		return diags
+	}
+
+	if isClientErr && cErr.message != "" {
+		msg := strings.ToLower(cErr.message)
+		for _, known := range knownAPIErrors {
+			if strings.Contains(msg, known.match) {
+				diags.AddError(known.summary, known.hint+"\n\n"+detail)
+
+				return diags
+			}
+		}
+	}
+
+	if isClientErr {
 		summary = cErr.op
 	} else if errors.Is(err, ErrInvalidAPIResponseBody) {
 		summary = "The API server response was invalid"
@@ -265,7 +1150,7 @@ func errToDiags(err error) diag.Diagnostics {
 		summary = "There was an API error"
 	}
 
-	diags.AddError(summary, err.Error())
+	diags.AddError(summary, detail)
 
 	return diags
 }
@@ -280,12 +1165,126 @@ func (r *NodeResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	if err := r.client.CreateNode(ctx, &plan); err != nil {
+	client, diags := r.clientFor(plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.AdoptExisting.ValueBool() {
+		node, err := client.FindExistingNode(ctx, plan.Name.ValueString(), plan.Network.ValueString())
+		if err != nil {
+			resp.Diagnostics.Append(errToDiags(err)...)
+
+			return
+		}
+
+		if node != nil {
+			plan.setComputed(node)
+			resp.Diagnostics.Append(plan.setEffectiveSettings(ctx, node)...)
+			plan.InitDuration = types.Int64Null()
+			plan.setRawJSON(node, r.exposeRawResponse)
+			resp.Diagnostics.AddWarning(
+				"Adopted existing node",
+				fmt.Sprintf("Node %s already existed and was adopted instead of creating a new one, "+
+					"its settings were left as they are.", plan.NodeID.ValueString()),
+			)
+			resp.Diagnostics.Append(plan.setTagsAll(ctx, r.defaultTags)...)
+			resp.Diagnostics.Append(
+				resp.State.Set(ctx, &plan)...,
+			)
+
+			return
+		}
+	}
+
+	extra, diags := plan.extraCreateParams(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	body, err := plan.createRequest()
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("settings").AtName("whitelist"), "Invalid whitelist", err.Error())
+
+		return
+	}
+
+	start := time.Now()
+	nodeID, err := client.CreateNode(ctx, body, extra)
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	// Record the node right away, waiting for it can take a long time and if
+	// it's interrupted the node must not be left out of the state. The rest
+	// of the state is null until the next read fills it in.
+	resp.Diagnostics.Append(
+		resp.State.SetAttribute(ctx, path.Root("node_id"), nodeID)...,
+	)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var status string
+	if plan.WaitForReady.ValueBool() {
+		status = plan.WaitForStatus.ValueString()
+	}
+	node, err := client.WaitNodeCreated(ctx, nodeID, status)
+	if err != nil {
 		resp.Diagnostics.Append(errToDiags(err)...)
 
 		return
 	}
+	plan.setComputed(node)
+	resp.Diagnostics.Append(plan.setEffectiveSettings(ctx, node)...)
+	plan.setRawJSON(node, r.exposeRawResponse)
+	plan.InitDuration = types.Int64Null()
+	if status != "" {
+		plan.InitDuration = types.Int64Value(int64(time.Since(start).Seconds()))
+	}
+
+	switch plan.PurchasedType.ValueString() {
+	case "trial":
+		expires := "soon"
+		if node.Expires != nil {
+			expires = "on " + *node.Expires
+		}
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("purchased_type"),
+			"Trial node will expire",
+			fmt.Sprintf("Trial nodes aren't renewed, this node will be deleted by Voltage %s.", expires),
+		)
+	case "ondemand":
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("purchased_type"),
+			"On demand node is billed by usage",
+			"On demand nodes are billed for as long as they exist, destroy the node when it's no longer needed.",
+		)
+	}
+
+	if pending := plan.pendingComputed(); len(pending) > 0 {
+		resp.Diagnostics.AddWarning(
+			"Some node attributes aren't available yet",
+			fmt.Sprintf("Voltage didn't report %s yet, they are left null and will be populated on the next refresh.",
+				strings.Join(pending, ", ")),
+		)
+	}
 
+	if want := plan.LndVersion.ValueString(); want != "" && !plan.RunningLndVersion.IsNull() &&
+		compareVersions(want, plan.RunningLndVersion.ValueString()) != 0 {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("lnd_version"),
+			"LND version mismatch",
+			fmt.Sprintf("Voltage can't select the LND version of new nodes, the node runs LND %s instead of %s.",
+				plan.RunningLndVersion.ValueString(), want),
+		)
+	}
+
+	resp.Diagnostics.Append(plan.setTagsAll(ctx, r.defaultTags)...)
 	resp.Diagnostics.Append(
 		resp.State.Set(ctx, &plan)...,
 	)
@@ -300,11 +1299,70 @@ func (r *NodeResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	if err := r.client.ReadNode(ctx, state.NodeID.ValueString()); err != nil {
+	client, diags := r.clientFor(state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	node, err := client.ReadNode(ctx, state.NodeID.ValueString())
+	if errors.Is(err, ErrNodeNotFound) {
+		// Deleted outside of Terraform, let it plan a new one.
+		resp.State.RemoveResource(ctx)
+
+		return
+	}
+	if err != nil {
 		resp.Diagnostics.Append(errToDiags(err)...)
 
 		return
 	}
+	// Voltage keeps listing deleted nodes for a while, they are gone too.
+	if isTerminal(node.Status) {
+		resp.State.RemoveResource(ctx)
+
+		return
+	}
+
+	state.setComputed(node)
+	resp.Diagnostics.Append(state.setEffectiveSettings(ctx, node)...)
+	state.setRawJSON(node, r.exposeRawResponse)
+
+	// A node whose creation failed only has its ID in state, take the
+	// settings it was created with.
+	if state.Settings == nil && node.Settings != nil {
+		state.Settings = &nodeSettingsModel{}
+		state.Settings.setFromAPI(node.Settings)
+	}
+
+	// States written before the optional settings had defaults hold null
+	// for them, which would plan a change to the default.
+	if state.Settings != nil {
+		state.Settings.setDefaults(ctx)
+	}
+
+	// Settings aren't refreshed as most of them can't be updated, a diff
+	// on them would only lead to an apply that fails.
+	if state.Settings != nil && node.Settings != nil {
+		var remote nodeSettingsModel
+		remote.setFromAPI(node.Settings)
+		drifted := state.Settings.driftedSettings(remote)
+		switch {
+		case len(drifted) == 0:
+		case state.Reconcile.ValueString() == "replace":
+			// Refresh them, so that the plan replaces the node to get back
+			// to the configuration.
+			state.Settings.copyFrom(remote, drifted)
+		default:
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("settings"),
+				"Node settings changed outside of Terraform",
+				fmt.Sprintf("The settings %s of node %s differ from the configuration and can't be updated in place. "+
+					"Revert them in the Voltage dashboard, or run terraform apply -replace to recreate the node.",
+					strings.Join(drifted, ", "), state.NodeID.ValueString()),
+			)
+		}
+	}
 
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
@@ -315,7 +1373,168 @@ func (r *NodeResource) Read(ctx context.Context, req resource.ReadRequest, resp
 
 }
 func (r *NodeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	resp.Diagnostics.AddError("Update not implemented", "You cannot update a node")
+	var plan, state nodeModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.clientFor(plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !reflect.DeepEqual(plan.immutable(), state.immutable()) {
+		resp.Diagnostics.AddError("Update not implemented", "You cannot update a node")
+
+		return
+	}
+
+	// Computed values are unknown in the plan, keep the ones we already know.
+	plan.NodeID = state.NodeID
+	plan.Created = state.Created
+	plan.Status = state.Status
+	plan.APIEndpoint = state.APIEndpoint
+	plan.RunningLndVersion = state.RunningLndVersion
+	plan.RawJSON = state.RawJSON
+	plan.Effective = state.Effective
+	plan.InitDuration = state.InitDuration
+	plan.IsMainnet = state.IsMainnet
+	plan.ExpiresAt = state.ExpiresAt
+
+	running := state.RunningLndVersion.ValueString()
+	if want := plan.LndVersion.ValueString(); want != "" && !plan.LndVersion.Equal(state.LndVersion) {
+		switch compareVersions(want, running) {
+		case -1:
+			resp.Diagnostics.AddAttributeError(
+				path.Root("lnd_version"),
+				"LND downgrades are not supported",
+				fmt.Sprintf("The node runs LND %s and can't be downgraded to %s.", running, want),
+			)
+
+			return
+		case 1:
+			version, err := client.UpgradeNode(ctx, plan.NodeID.ValueString())
+			if err != nil {
+				resp.Diagnostics.Append(errToDiags(err)...)
+
+				return
+			}
+
+			if compareVersions(version, want) != 0 {
+				resp.Diagnostics.AddAttributeWarning(
+					path.Root("lnd_version"),
+					"LND version mismatch",
+					fmt.Sprintf("Voltage upgraded the node to its latest release, LND %s, instead of %s.", version, want),
+				)
+			}
+			plan.RunningLndVersion = types.StringValue(version)
+		}
+	}
+
+	if !reflect.DeepEqual(plan.Settings.Whitelist, state.Settings.Whitelist) {
+		nodeID := plan.NodeID.ValueString()
+		ips, err := whitelistIPs(plan.Settings.Whitelist)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("settings").AtName("whitelist"), "Invalid whitelist", err.Error())
+
+			return
+		}
+		if err := client.UpdateWhitelist(ctx, nodeID, ips); err != nil {
+			// Point at the offending entry when the API tells which one it is.
+			var wErr *WhitelistEntryError
+			if errors.As(err, &wErr) {
+				for i, w := range plan.Settings.Whitelist {
+					if normalizeIP(w.ValueString()) == wErr.Entry {
+						resp.Diagnostics.AddAttributeError(
+							path.Root("settings").AtName("whitelist").AtListIndex(i),
+							"Invalid whitelist entry",
+							err.Error(),
+						)
+
+						return
+					}
+				}
+			}
+
+			resp.Diagnostics.Append(errToDiags(err)...)
+
+			return
+		}
+
+		// Store what Voltage actually applied.
+		node, err := client.ReadNode(ctx, nodeID)
+		if err != nil {
+			resp.Diagnostics.Append(errToDiags(err)...)
+
+			return
+		}
+		resp.Diagnostics.Append(plan.setEffectiveSettings(ctx, node)...)
+		// Keep the configured entries unless they don't normalize to the
+		// same whitelist, so that formatting alone doesn't cause a diff.
+		if node.Settings != nil && node.Settings.Whitelist != nil {
+			applied := normalizeWhitelist(*node.Settings.Whitelist)
+			if !reflect.DeepEqual(applied, ips) {
+				plan.Settings.Whitelist = each(applied, types.StringValue)
+			}
+		}
+	}
+
+	// Those settings can be changed in place, all of them at once as the
+	// API requires them all.
+	if !plan.Settings.Grpc.Equal(state.Settings.Grpc) || !plan.Settings.Rest.Equal(state.Settings.Rest) ||
+		!plan.Settings.Keysend.Equal(state.Settings.Keysend) || !plan.Settings.Alias.Equal(state.Settings.Alias) ||
+		!plan.Settings.Color.Equal(state.Settings.Color) {
+		nodeID := plan.NodeID.ValueString()
+		settings, err := plan.Settings.toAPI()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("settings").AtName("whitelist"), "Invalid whitelist", err.Error())
+
+			return
+		}
+		if err := client.UpdateSettings(ctx, nodeID, settings); err != nil {
+			resp.Diagnostics.Append(errToDiags(err)...)
+
+			return
+		}
+
+		// Store what Voltage actually applied.
+		node, err := client.ReadNode(ctx, nodeID)
+		if err != nil {
+			resp.Diagnostics.Append(errToDiags(err)...)
+
+			return
+		}
+		plan.setComputed(node)
+		resp.Diagnostics.Append(plan.setEffectiveSettings(ctx, node)...)
+		if s := node.Settings; s != nil {
+			if s.Grpc != nil {
+				plan.Settings.Grpc = types.BoolValue(*s.Grpc)
+			}
+			if s.Rest != nil {
+				plan.Settings.Rest = types.BoolValue(*s.Rest)
+			}
+			if s.Keysend != nil {
+				plan.Settings.Keysend = types.BoolValue(*s.Keysend)
+			}
+			if s.Alias != nil {
+				plan.Settings.Alias = types.StringValue(*s.Alias)
+			}
+			// Voltage may change the case of the hex color, which is
+			// the same color.
+			if c := s.Color; c != nil && !strings.EqualFold(*c, plan.Settings.Color.ValueString()) {
+				plan.Settings.Color = types.StringValue(*c)
+			}
+		}
+	}
+
+	resp.Diagnostics.Append(plan.setTagsAll(ctx, r.defaultTags)...)
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &plan)...,
+	)
 }
 
 func (r *NodeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -327,9 +1546,88 @@ func (r *NodeResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	if err := r.client.DeleteNode(ctx, state.NodeID.ValueString()); err != nil {
+	client, diags := r.clientFor(state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A node that is already gone is as good as deleted.
+	err := client.DeleteNode(ctx, state.NodeID.ValueString())
+	if err != nil && !errors.Is(err, ErrNodeNotFound) {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		// The API documents no message for deletions it refuses, channels
+		// still being open or pending is the usual reason.
+		var cErr *ClientError
+		if errors.As(err, &cErr) && cErr.statusCode == http.StatusBadRequest {
+			resp.Diagnostics.AddWarning(
+				"Node may need to be drained before deletion",
+				"Voltage refused to delete the node. If it has open or pending channels, close them and wait "+
+					"for the closing transactions to confirm, then retry.",
+			)
+		}
+
+		return
+	}
+}
+
+// ImportState imports a node by its ID, optionally qualified by its network
+// as in "mainnet:<node_id>".
+func (r *NodeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	network, nodeID, qualified := strings.Cut(req.ID, ":")
+	if !qualified {
+		network, nodeID = "", req.ID
+	}
+
+	if qualified && !contains(nodeNetworks, network) {
+		resp.Diagnostics.AddError(
+			"Invalid import ID",
+			fmt.Sprintf("Expected <node_id> or <network>:<node_id> with network 'mainnet' or 'testnet', got: %q", req.ID),
+		)
+
+		return
+	}
+
+	node, err := r.client.ReadNode(ctx, nodeID)
+	if err != nil {
 		resp.Diagnostics.Append(errToDiags(err)...)
 
 		return
 	}
+
+	if qualified && (node.Network == nil || *node.Network != network) {
+		resp.Diagnostics.AddError(
+			"Invalid import ID",
+			fmt.Sprintf("Node %s doesn't run on %s.", nodeID, network),
+		)
+
+		return
+	}
+
+	// Settings is a required object, so the whole node is imported at once
+	// rather than leaving it for Read.
+	var state nodeModel
+	state.setComputed(node)
+	resp.Diagnostics.Append(state.setEffectiveSettings(ctx, node)...)
+	state.InitDuration = types.Int64Null()
+	state.setRawJSON(node, r.exposeRawResponse)
+	state.Name = types.StringPointerValue(node.NodeName)
+	state.Network = types.StringPointerValue(node.Network)
+	state.PurchasedType = types.StringPointerValue(node.PurchasedType)
+	state.Type = types.StringPointerValue(node.Type)
+	state.Tags = types.MapNull(types.StringType)
+	resp.Diagnostics.Append(state.setTagsAll(ctx, r.defaultTags)...)
+	state.WaitForStatus = types.StringValue("waiting_init")
+	state.WaitForReady = types.BoolValue(true)
+	state.ExtraParams = types.MapNull(types.StringType)
+	state.Settings = &nodeSettingsModel{}
+	if node.Settings != nil {
+		state.Settings.setFromAPI(node.Settings)
+	}
+	state.Settings.setDefaults(ctx)
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
 }
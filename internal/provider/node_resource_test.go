@@ -0,0 +1,1314 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/qustavo/terraform-provider-voltage/internal/fakevoltage"
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+func TestNodeResourceLifecycle(t *testing.T) {
+	for _, status := range []string{"waiting_init", "running"} {
+		t.Run(status, func(t *testing.T) {
+			p := newTestProvider(t, nil)
+			config := testNodeConfig(map[string]any{"wait_for_status": status})
+
+			state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+			requireNoErrors(t, diags)
+			node := nodeState(t, state)
+			if got := node.Status.ValueString(); got != status {
+				t.Errorf("status = %q, want %q", got, status)
+			}
+			if node.NodeID.IsNull() || node.APIEndpoint.IsNull() || node.RunningLndVersion.IsNull() {
+				t.Errorf("computed attributes not set: %+v", node)
+			}
+			nodeID := node.NodeID.ValueString()
+			if _, ok := p.api.Node(nodeID); !ok {
+				t.Fatalf("node %s wasn't created", nodeID)
+			}
+
+			state, diags = p.read("voltage_node", state)
+			requireNoErrors(t, diags)
+			if got := nodeState(t, state).NodeID.ValueString(); got != nodeID {
+				t.Errorf("node_id after refresh = %q, want %q", got, nodeID)
+			}
+
+			plan, planned := p.plan("voltage_node", state, config)
+			requireNoErrors(t, plan.Diagnostics)
+			if !planned.Equal(state) {
+				t.Errorf("unchanged configuration plans changes:\n%s\n%s", planned, state)
+			}
+
+			state, diags = p.apply("voltage_node", state, nil)
+			requireNoErrors(t, diags)
+			if !state.IsNull() {
+				t.Errorf("state after destroy = %s, want null", state)
+			}
+			if remote, _ := p.api.Node(nodeID); remote.Status == nil || *remote.Status != "deleted" {
+				t.Errorf("node %s wasn't deleted", nodeID)
+			}
+		})
+	}
+}
+
+func TestNodeResourceDeletedOutsideTerraform(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	// Delete it through another copy of the state.
+	_, diags = p.apply("voltage_node", state, nil)
+	requireNoErrors(t, diags)
+
+	// Destroying a node that is already gone succeeds.
+	_, diags = p.apply("voltage_node", state, nil)
+	requireNoErrors(t, diags)
+
+	// Refreshing it removes it from the state, so that it's planned again.
+	state, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	if !state.IsNull() {
+		t.Errorf("state of a deleted node = %s, want null", state)
+	}
+}
+
+func TestNodeResourceReadDeletedStatus(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	// Voltage still answers for the node, with its status set to deleted.
+	p.api.UpdateNode(nodeState(t, state).NodeID.ValueString(), func(n *voltage.NodeDocument) {
+		n.Status = toPtr("deleted")
+	})
+
+	state, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	if !state.IsNull() {
+		t.Errorf("state of a node with status deleted = %s, want null", state)
+	}
+}
+
+func TestNodeResourceAdoptExisting(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	existing := nodeState(t, state).NodeID.ValueString()
+
+	config := testNodeConfig(map[string]any{"adopt_existing": true})
+	state, diags = p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	if got := nodeState(t, state).NodeID.ValueString(); got != existing {
+		t.Errorf("adopted node_id = %s, want %s", got, existing)
+	}
+	if findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Adopted existing node") == nil {
+		t.Errorf("adopting a node didn't warn, got %v", summaries(diags))
+	}
+
+	// A deleted node with the same name can't be adopted, a new one is
+	// created instead.
+	p.api.UpdateNode(existing, func(n *voltage.NodeDocument) {
+		n.Status = toPtr("deleted")
+	})
+	state, diags = p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	if got := nodeState(t, state).NodeID.ValueString(); got == existing {
+		t.Errorf("adopted the deleted node %s", got)
+	}
+	if findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Adopted existing node") != nil {
+		t.Error("creating a node warned that it was adopted")
+	}
+}
+
+func TestNodeResourceTagsAll(t *testing.T) {
+	tagsAll := func(v tftypes.Value) map[string]string {
+		t.Helper()
+
+		var all map[string]string
+		if diags := nodeState(t, v).TagsAll.ElementsAs(context.Background(), &all, false); diags.HasError() {
+			t.Fatalf("decoding tags_all: %v", diags)
+		}
+
+		return all
+	}
+
+	p := newTestProvider(t, map[string]any{"default_tags": map[string]any{"env": "prod", "team": "infra"}})
+	config := testNodeConfig(map[string]any{"tags": map[string]any{"team": "voltage", "owner": "me"}})
+
+	// The node tags take precedence over the default ones.
+	want := map[string]string{"env": "prod", "team": "voltage", "owner": "me"}
+	plan, planned := p.plan("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, plan.Diagnostics)
+	if got := tagsAll(planned); !reflect.DeepEqual(got, want) {
+		t.Errorf("planned tags_all = %v, want %v", got, want)
+	}
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	if got := tagsAll(state); !reflect.DeepEqual(got, want) {
+		t.Errorf("tags_all = %v, want %v", got, want)
+	}
+
+	// Changing only the default tags changes the node.
+	requireNoErrors(t, p.configure(map[string]any{"default_tags": map[string]any{"env": "staging"}}))
+	want = map[string]string{"env": "staging", "team": "voltage", "owner": "me"}
+	plan, planned = p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if planned.Equal(state) {
+		t.Error("changing default_tags planned no changes")
+	}
+	if got := tagsAll(planned); !reflect.DeepEqual(got, want) {
+		t.Errorf("planned tags_all after changing default_tags = %v, want %v", got, want)
+	}
+	state, diags = p.apply("voltage_node", state, config)
+	requireNoErrors(t, diags)
+	if got := tagsAll(state); !reflect.DeepEqual(got, want) {
+		t.Errorf("tags_all after changing default_tags = %v, want %v", got, want)
+	}
+
+	// Tags only known at apply time leave tags_all unknown.
+	config["tags"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, tftypes.UnknownValue)
+	plan, planned = p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if !nodeState(t, planned).TagsAll.IsUnknown() {
+		t.Errorf("tags_all with unknown tags = %s, want unknown", nodeState(t, planned).TagsAll)
+	}
+}
+
+func TestNodeResourceReadFillsDefaults(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+
+	// Mimic a state written before wumbo and amp had defaults.
+	ctx := context.Background()
+	node := nodeState(t, state)
+	node.Settings.Wumbo = types.BoolNull()
+	node.Settings.Amp = types.BoolNull()
+	old := tfsdk.State{Schema: nodeSchemaV1, Raw: state}
+	if diags := old.Set(ctx, &node); diags.HasError() {
+		t.Fatalf("encoding node state: %v", diags)
+	}
+
+	state, diags = p.read("voltage_node", old.Raw)
+	requireNoErrors(t, diags)
+	node = nodeState(t, state)
+	if node.Settings.Wumbo.IsNull() || node.Settings.Wumbo.ValueBool() {
+		t.Errorf("wumbo after refresh = %s, want false", node.Settings.Wumbo)
+	}
+	if node.Settings.Amp.IsNull() || !node.Settings.Amp.ValueBool() {
+		t.Errorf("amp after refresh = %s, want true", node.Settings.Amp)
+	}
+
+	plan, planned := p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if !planned.Equal(state) {
+		t.Errorf("refreshed state plans changes:\n%s\n%s", planned, state)
+	}
+}
+
+func TestNodeResourceUpgrade(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(map[string]any{"wait_for_status": "running"})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	config["lnd_version"] = fakevoltage.LatestLndVersion
+	state, diags = p.apply("voltage_node", state, config)
+	requireNoErrors(t, diags)
+	if got := nodeState(t, state).RunningLndVersion.ValueString(); got != fakevoltage.LatestLndVersion {
+		t.Errorf("running_lnd_version = %s, want %s", got, fakevoltage.LatestLndVersion)
+	}
+	if remote, _ := p.api.Node(nodeID); *remote.Status != "running" {
+		t.Errorf("status after the upgrade = %s, want running", *remote.Status)
+	}
+}
+
+func TestNodeResourceUpdateWhitelist(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	// Only the whitelist changes, it is updated without restarting the
+	// node through /node/settings.
+	config["settings"] = testSettings(map[string]any{"whitelist": []any{"1.2.3.4", "10.0.0.0/8"}})
+	state, diags = p.apply("voltage_node", state, config)
+	requireNoErrors(t, diags)
+	if got := p.api.Calls("/node/whitelist"); got != 1 {
+		t.Errorf("/node/whitelist called %d times, want 1", got)
+	}
+	if got := p.api.Calls("/node/settings"); got != 0 {
+		t.Errorf("/node/settings called %d times, want 0", got)
+	}
+	if remote, _ := p.api.Node(nodeID); !reflect.DeepEqual(*remote.Settings.Whitelist, []string{"1.2.3.4", "10.0.0.0/8"}) {
+		t.Errorf("whitelist = %v, want the configured one", *remote.Settings.Whitelist)
+	}
+
+	// A rejected entry is pointed at.
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/node/whitelist" {
+			writeJSON(w, http.StatusBadRequest, `{"message": "192.168.0.1 is not allowed"}`)
+
+			return
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(rejecting.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": rejecting.URL}))
+
+	config["settings"] = testSettings(map[string]any{"whitelist": []any{"1.2.3.4", "192.168.0.1"}})
+	_, diags = p.apply("voltage_node", state, config)
+	d := findDiag(diags, tfprotov6.DiagnosticSeverityError, "Invalid whitelist entry")
+	if d == nil {
+		t.Fatalf("got %v, want an invalid whitelist entry error", summaries(diags))
+	}
+	want := tftypes.NewAttributePath().WithAttributeName("settings").WithAttributeName("whitelist").WithElementKeyInt(1)
+	if !d.Attribute.Equal(want) {
+		t.Errorf("error attribute = %s, want %s", d.Attribute, want)
+	}
+}
+
+func TestNodeResourceEffectiveSettings(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	node := nodeState(t, state)
+	var effective nodeSettingsModel
+	if diags := node.Effective.As(context.Background(), &effective, basetypes.ObjectAsOptions{}); diags.HasError() {
+		t.Fatalf("decoding effective_settings: %v", diags)
+	}
+	if effective.Alias.ValueString() != "test-node" {
+		t.Errorf("effective alias after create = %s, want test-node", effective.Alias)
+	}
+
+	p.api.UpdateNode(node.NodeID.ValueString(), func(n *voltage.NodeDocument) {
+		n.Settings.Alias = toPtr("changed")
+	})
+	state, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+
+	node = nodeState(t, state)
+	if diags := node.Effective.As(context.Background(), &effective, basetypes.ObjectAsOptions{}); diags.HasError() {
+		t.Fatalf("decoding effective_settings: %v", diags)
+	}
+	if effective.Alias.ValueString() != "changed" {
+		t.Errorf("effective alias after refresh = %s, want changed", effective.Alias)
+	}
+	if node.Settings.Alias.ValueString() != "test-node" {
+		t.Errorf("configured alias after refresh = %s, want it unchanged", node.Settings.Alias)
+	}
+}
+
+func TestNodeResourceFailedWaitKeepsNode(t *testing.T) {
+	p := newTestProvider(t, nil)
+	// The fake API never gets there, the wait gives up after two checks.
+	config := testNodeConfig(map[string]any{"wait_for_status": "waiting_unlock", "max_poll_attempts": 2})
+
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	if !hasErrors(diags) {
+		t.Fatal("creating a node that never gets ready succeeded")
+	}
+
+	// Terraform records it as tainted, every operation must handle its
+	// partial state.
+	node := nodeState(t, state)
+	if node.NodeID.IsNull() || node.Settings != nil {
+		t.Fatalf("state after a failed wait = %+v, want only the node ID", node)
+	}
+	nodeID := node.NodeID.ValueString()
+
+	plan, _ := p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+
+	state, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	node = nodeState(t, state)
+	if node.Settings == nil || node.Settings.Alias.ValueString() != "test-node" {
+		t.Errorf("settings after refresh = %+v, want the ones of the node", node.Settings)
+	}
+
+	plan, _ = p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+
+	_, diags = p.apply("voltage_node", state, nil)
+	requireNoErrors(t, diags)
+	if remote, _ := p.api.Node(nodeID); remote.Status == nil || *remote.Status != "deleted" {
+		t.Errorf("node %s wasn't deleted", nodeID)
+	}
+}
+
+func TestNodeResourceUpdateAliasKeepsOtherSettings(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"wumbo": true})})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+
+	config["settings"] = testSettings(map[string]any{"wumbo": true, "alias": "renamed", "color": "#000000"})
+	state, diags = p.apply("voltage_node", state, config)
+	requireNoErrors(t, diags)
+
+	node := nodeState(t, state)
+	if node.Settings.Alias.ValueString() != "renamed" || node.Settings.Color.ValueString() != "#000000" {
+		t.Errorf("settings after update = %+v", node.Settings)
+	}
+
+	// The API replaces all the settings with the ones sent.
+	remote, _ := p.api.Node(node.NodeID.ValueString())
+	if s := remote.Settings; *s.Alias != "renamed" || s.Wumbo == nil || !*s.Wumbo || s.Whitelist == nil || len(*s.Whitelist) != 1 {
+		t.Errorf("node settings after update = %+v, want the full settings", s)
+	}
+}
+
+func TestNodeResourceUpdateSettingsInPlace(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	config := testNodeConfig(map[string]any{
+		"settings": testSettings(map[string]any{"grpc": false, "rest": false, "keysend": false, "alias": "renamed"}),
+	})
+	plan, _ := p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if len(plan.RequiresReplace) > 0 {
+		t.Fatalf("updating settings in place plans to replace the node: %v", plan.RequiresReplace)
+	}
+
+	state, diags = p.apply("voltage_node", state, config)
+	requireNoErrors(t, diags)
+	if got := p.api.Calls("/node/settings"); got != 1 {
+		t.Errorf("made %d settings updates, want a single one", got)
+	}
+
+	node := nodeState(t, state)
+	remote, _ := p.api.Node(node.NodeID.ValueString())
+	for name, v := range map[string]*bool{"grpc": remote.Settings.Grpc, "rest": remote.Settings.Rest, "keysend": remote.Settings.Keysend} {
+		if v == nil || *v {
+			t.Errorf("node %s after update = %v, want false", name, v)
+		}
+	}
+	if node.Settings.Grpc.ValueBool() || node.Settings.Rest.ValueBool() || node.Settings.Keysend.ValueBool() ||
+		node.Settings.Alias.ValueString() != "renamed" {
+		t.Errorf("settings after update = %+v", node.Settings)
+	}
+
+	plan, planned := p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if !planned.Equal(state) {
+		t.Error("the configuration still plans changes after the update")
+	}
+}
+
+func TestNodeResourceBoolSettingsDefaults(t *testing.T) {
+	p := newTestProvider(t, nil)
+	plan, planned := p.plan("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, plan.Diagnostics)
+
+	// Omitted settings plan known values, the ones of a new Voltage node.
+	settings := nodeState(t, planned).Settings
+	for name, tc := range map[string]struct {
+		got  types.Bool
+		want bool
+	}{
+		"amp":            {settings.Amp, true},
+		"autocompaction": {settings.AutoCompactation, true},
+		"wumbo":          {settings.Wumbo, false},
+		"wtclient":       {settings.WtClient, false},
+		"zeroconf":       {settings.ZeroConf, false},
+	} {
+		if tc.got.IsUnknown() || tc.got.IsNull() || tc.got.ValueBool() != tc.want {
+			t.Errorf("planned %s = %s, want %t", name, tc.got, tc.want)
+		}
+	}
+
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	remote, _ := p.api.Node(nodeState(t, state).NodeID.ValueString())
+	if s := remote.Settings; s.Amp == nil || !*s.Amp || s.Autocompaction == nil || !*s.Autocompaction ||
+		s.Wumbo == nil || *s.Wumbo {
+		t.Errorf("node created with amp %v, autocompaction %v and wumbo %v", s.Amp, s.Autocompaction, s.Wumbo)
+	}
+}
+
+func TestNodeResourceStringSettingsDefaults(t *testing.T) {
+	p := newTestProvider(t, nil)
+	plan, planned := p.plan("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, plan.Diagnostics)
+
+	// Only the settings Voltage documents a value for are defaulted, the
+	// others are left to Voltage.
+	settings := nodeState(t, planned).Settings
+	for name, tc := range map[string]struct {
+		got  types.String
+		want types.String
+	}{
+		"maxpendingchannels": {settings.MaxPendingChannels, types.StringValue("2")},
+		"numgraphsyncpeers":  {settings.NumGraphSyncPeers, types.StringValue("3")},
+		"minchansize":        {settings.MinChanSize, types.StringNull()},
+		"maxchansize":        {settings.MaxChanSize, types.StringNull()},
+		"defaultfeerate":     {settings.DefaultFeeRate, types.StringNull()},
+		"basefee":            {settings.BaseFee, types.StringNull()},
+	} {
+		if !tc.got.Equal(tc.want) {
+			t.Errorf("planned %s = %s, want %s", name, tc.got, tc.want)
+		}
+	}
+
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	remote, _ := p.api.Node(nodeState(t, state).NodeID.ValueString())
+	if s := remote.Settings; s.Minchansize != nil || s.Defaultfeerate != nil || s.Basefee != nil ||
+		s.Maxpendingchannels == nil || *s.Maxpendingchannels != "2" {
+		t.Errorf("node created with minchansize %v, defaultfeerate %v, basefee %v and maxpendingchannels %v",
+			s.Minchansize, s.Defaultfeerate, s.Basefee, s.Maxpendingchannels)
+	}
+}
+
+func TestNodeResourceImportPlansNoChanges(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	// Report the settings like Voltage does: unset ones are empty strings
+	// and some of the defaulted ones are missing.
+	p.api.UpdateNode(nodeID, func(n *voltage.NodeDocument) {
+		s := n.Settings
+		s.Webhook, s.Minchansize, s.Maxchansize, s.Defaultfeerate = toPtr(""), toPtr(""), toPtr(""), toPtr("")
+		s.Wumbo, s.Amp, s.Numgraphsyncpeers = nil, nil, nil
+	})
+
+	imported, diags := p.importState("voltage_node", nodeID)
+	requireNoErrors(t, diags)
+
+	// A configuration matching the node, without the attributes that only
+	// live in Terraform.
+	config := testNodeConfig(map[string]any{"poll_interval": nil})
+	plan, planned := p.plan("voltage_node", imported, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if len(plan.RequiresReplace) > 0 {
+		t.Errorf("imported node plans to be replaced: %v", plan.RequiresReplace)
+	}
+	if !planned.Equal(imported) {
+		t.Errorf("imported node plans changes:\nplanned:  %s\nimported: %s", planned, imported)
+	}
+}
+
+func TestNodeResourceAuthenticationFailure(t *testing.T) {
+	p := newTestProvider(t, map[string]any{"token": "wrong-token"})
+	_, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+
+	d := findDiag(diags, tfprotov6.DiagnosticSeverityError, "Authentication failed, check your Voltage API token")
+	if d == nil {
+		t.Fatalf("got %v, want an authentication error", summaries(diags))
+	}
+	if !strings.Contains(d.Detail, "VOLTAGE_TOKEN") {
+		t.Errorf("detail %q doesn't explain how to set the token", d.Detail)
+	}
+}
+
+func TestNodeResourceComputedFromFinalPoll(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(map[string]any{"wait_for_status": "running"}))
+	requireNoErrors(t, diags)
+	got := nodeState(t, state)
+
+	// The node was waiting_init when created and only running by the last
+	// status check, so every computed attribute has to come from the node
+	// read once it got there.
+	node, ok := p.api.Node(got.NodeID.ValueString())
+	if !ok {
+		t.Fatalf("node %s wasn't created", got.NodeID)
+	}
+	for name, attr := range map[string]struct {
+		got  types.String
+		want *string
+	}{
+		"status":              {got.Status, node.Status},
+		"created":             {got.Created, node.Created},
+		"api_endpoint":        {got.APIEndpoint, node.ApiEndpoint},
+		"running_lnd_version": {got.RunningLndVersion, node.LndVersion},
+	} {
+		if want := types.StringPointerValue(attr.want); !attr.got.Equal(want) {
+			t.Errorf("%s = %s, want %s", name, attr.got, want)
+		}
+	}
+	if got.Status.ValueString() != "running" {
+		t.Errorf("status = %s, want running", got.Status)
+	}
+}
+
+func TestErrToDiagsIncludesRequest(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"error status": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusInternalServerError, `{"message": "boom"}`)
+		},
+		"authentication": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusUnauthorized, `{"message": "invalid token"}`)
+		},
+		"known API error": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusBadRequest, `{"message": "Insufficient funds"}`)
+		},
+		"invalid body": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+		"no response": func(w http.ResponseWriter, r *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newTestClient(t, handler, ClientConfig{})
+			_, err := c.ReadNode(context.Background(), "node-1")
+			if err == nil {
+				t.Fatal("reading the node succeeded")
+			}
+
+			diags := errToDiags(err)
+			if len(diags) != 1 {
+				t.Fatalf("got %d diagnostics, want 1", len(diags))
+			}
+			if detail := diags[0].Detail(); !strings.Contains(detail, "Request: POST /node") {
+				t.Errorf("detail %q doesn't include the request", detail)
+			}
+		})
+	}
+}
+
+func TestNodeResourceImportID(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	for id, wantErr := range map[string]bool{
+		nodeID:              false,
+		"testnet:" + nodeID: false,
+		"mainnet:" + nodeID: true,
+		"regtest:" + nodeID: true,
+	} {
+		imported, diags := p.importState("voltage_node", id)
+		if wantErr {
+			if findDiag(diags, tfprotov6.DiagnosticSeverityError, "Invalid import ID") == nil {
+				t.Errorf("importing %q: got %v, want an invalid import ID error", id, summaries(diags))
+			}
+			continue
+		}
+
+		requireNoErrors(t, diags)
+		node := nodeState(t, imported)
+		if node.NodeID.ValueString() != nodeID || node.Network.ValueString() != "testnet" {
+			t.Errorf("importing %q: got node %s on %s, want %s on testnet", id, node.NodeID, node.Network, nodeID)
+		}
+	}
+}
+
+func TestNodeResourceWebhookSecretRequiresWebhook(t *testing.T) {
+	p := newTestProvider(t, nil)
+	for name, tc := range map[string]struct {
+		settings map[string]any
+		wantErr  bool
+	}{
+		"secret without webhook": {
+			settings: map[string]any{"webhook_secret": "secret"},
+			wantErr:  true,
+		},
+		"secret with webhook": {
+			settings: map[string]any{"webhook": "https://example.com/webhook", "webhook_secret": "secret"},
+		},
+		"webhook without secret": {
+			settings: map[string]any{"webhook": "https://example.com/webhook"},
+		},
+	} {
+		diags := p.validate("voltage_node", testNodeConfig(map[string]any{"settings": testSettings(tc.settings)}))
+		d := findDiag(diags, tfprotov6.DiagnosticSeverityError, "Invalid Attribute Combination")
+		if tc.wantErr && (d == nil || !strings.Contains(d.Detail, "webhook")) {
+			t.Errorf("%s: got %v, want an error pointing at webhook", name, summaries(diags))
+		}
+		if !tc.wantErr && hasErrors(diags) {
+			t.Errorf("%s: unexpected errors %v", name, summaries(diags))
+		}
+	}
+}
+
+func TestNodeResourceRawJSON(t *testing.T) {
+	for _, expose := range []bool{false, true} {
+		p := newTestProvider(t, map[string]any{"expose_raw_response": expose})
+		config := testNodeConfig(map[string]any{
+			"settings": testSettings(map[string]any{"webhook": "https://example.com/webhook", "webhook_secret": "hunter2"}),
+		})
+		state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+		requireNoErrors(t, diags)
+
+		node := nodeState(t, state)
+		if !expose {
+			if !node.RawJSON.IsNull() {
+				t.Errorf("raw_json = %s, want null unless expose_raw_response is set", node.RawJSON)
+			}
+			continue
+		}
+
+		var raw map[string]any
+		if err := json.Unmarshal([]byte(node.RawJSON.ValueString()), &raw); err != nil {
+			t.Fatalf("raw_json %s: %v", node.RawJSON, err)
+		}
+		if raw["node_id"] != node.NodeID.ValueString() {
+			t.Errorf("raw_json node_id = %v, want %s", raw["node_id"], node.NodeID)
+		}
+		if strings.Contains(node.RawJSON.ValueString(), "hunter2") {
+			t.Errorf("raw_json %s leaks the webhook secret", node.RawJSON)
+		}
+	}
+}
+
+func TestNodeResourceEmptyWhitelistWarning(t *testing.T) {
+	p := newTestProvider(t, nil)
+	for name, tc := range map[string]struct {
+		whitelist []any
+		wantWarn  bool
+	}{
+		"empty":     {whitelist: []any{}, wantWarn: true},
+		"not empty": {whitelist: []any{"1.2.3.4"}},
+	} {
+		diags := p.validate("voltage_node", testNodeConfig(map[string]any{
+			"settings": testSettings(map[string]any{"whitelist": tc.whitelist}),
+		}))
+		requireNoErrors(t, diags)
+		if warned := findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Empty whitelist") != nil; warned != tc.wantWarn {
+			t.Errorf("%s whitelist: warned = %t, want %t", name, warned, tc.wantWarn)
+		}
+	}
+}
+
+func TestErrToDiagsKnownAPIErrors(t *testing.T) {
+	for message, want := range map[string]string{
+		"node subscription is not active":    "Node subscription is not active",
+		"maxchansize is invalid":             "Invalid maxchansize",
+		"Node is already up to date":         "Node already up to date",
+		"missing required field.":            "Missing required field",
+		"VALIDATION ERROR: name is too long": "Invalid request",
+		"settings is invalid":                "calling POST /node/create",
+	} {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusBadRequest, mustJSON(t, map[string]string{"message": message}))
+		}, ClientConfig{})
+
+		_, err := c.CreateNode(context.Background(), voltage.PostNodeCreateJSONRequestBody{Name: "node"}, nil)
+		diags := errToDiags(err)
+		if len(diags) != 1 || diags[0].Summary() != want {
+			t.Errorf("%q: got %v, want a %q error", message, diags, want)
+			continue
+		}
+		if !strings.Contains(diags[0].Detail(), message) {
+			t.Errorf("%q: detail %q doesn't include the API message", message, diags[0].Detail())
+		}
+	}
+}
+
+func TestNodeResourceDeleteRefused(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	// Refuse deletions, and leave the rest to the fake API.
+	refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/node/delete" {
+			writeJSON(w, http.StatusBadRequest, `{"message": "node can't be deleted"}`)
+
+			return
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(refusing.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": refusing.URL}))
+
+	_, diags = p.apply("voltage_node", state, nil)
+	if !hasErrors(diags) {
+		t.Fatal("a refused deletion succeeded")
+	}
+	if d := findDiag(diags, tfprotov6.DiagnosticSeverityError, "POST /node/delete"); d == nil || !strings.Contains(d.Detail, "node can't be deleted") {
+		t.Errorf("the error doesn't include the API message, got %v", summaries(diags))
+	}
+	if findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Node may need to be drained") == nil {
+		t.Errorf("a refused deletion didn't hint at draining the node, got %v", summaries(diags))
+	}
+}
+
+func TestNodeResourceMaxRetries(t *testing.T) {
+	p := newTestProvider(t, map[string]any{"max_retries": 1})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	// Fail the next two reads of the node, and leave the rest to the fake
+	// API.
+	var mu sync.Mutex
+	var failures int
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fail := r.URL.Path == "/node" && failures < 2
+		if fail {
+			failures++
+		}
+		mu.Unlock()
+		if fail {
+			writeJSON(w, http.StatusServiceUnavailable, `{"message": "try again"}`)
+
+			return
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(flaky.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": flaky.URL, "max_retries": 1}))
+
+	if _, diags := p.read("voltage_node", state); !hasErrors(diags) {
+		t.Fatal("reading succeeded with the provider max_retries")
+	}
+
+	state, diags = p.apply("voltage_node", state, testNodeConfig(map[string]any{"max_retries": 2}))
+	requireNoErrors(t, diags)
+	mu.Lock()
+	failures = 0
+	mu.Unlock()
+	_, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+}
+
+func TestNodeResourceExtraCreateParamsCollision(t *testing.T) {
+	p := newTestProvider(t, nil)
+	for key, wantWarn := range map[string]bool{
+		"name":   true,
+		"coupon": false,
+	} {
+		config := testNodeConfig(map[string]any{"extra_create_params": map[string]any{key: `"value"`}})
+		diags := p.validate("voltage_node", config)
+		requireNoErrors(t, diags)
+		if warned := findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Extra create parameter is ignored") != nil; warned != wantWarn {
+			t.Errorf("%s: warned = %t, want %t", key, warned, wantWarn)
+		}
+	}
+}
+
+func TestNodeSettingsToAPIEmptyStrings(t *testing.T) {
+	settings := nodeSettingsModel{}
+	settings.setFromAPI(&voltage.NodeSettings{Whitelist: &[]string{}})
+	settings.Alias = types.StringValue("")
+	settings.Color = types.StringValue("")
+	settings.Webhook = types.StringValue("")
+
+	api, err := settings.toAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// /node/settings requires alias and color, even empty.
+	if api.Alias == nil || *api.Alias != "" || api.Color == nil || *api.Color != "" {
+		t.Errorf("alias = %v, color = %v, want both sent empty", api.Alias, api.Color)
+	}
+	if api.Webhook != nil {
+		t.Errorf("webhook = %q, want it left out", *api.Webhook)
+	}
+}
+
+func TestNodeResourceWaitForStatus(t *testing.T) {
+	for status, wantPolls := range map[string]int{
+		"waiting_init": 1,
+		// New nodes are listed waiting_init first.
+		"running": 2,
+	} {
+		t.Run(status, func(t *testing.T) {
+			p := newTestProvider(t, nil)
+			config := testNodeConfig(map[string]any{"wait_for_status": status})
+			state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+			requireNoErrors(t, diags)
+			if got := nodeState(t, state).Status.ValueString(); got != status {
+				t.Errorf("status = %q, want %q", got, status)
+			}
+			// The node is read once it got there.
+			if got := p.api.Calls("/node") - 1; got != wantPolls {
+				t.Errorf("checked the status %d times, want %d", got, wantPolls)
+			}
+		})
+	}
+
+	p := newTestProvider(t, nil)
+	diags := p.validate("voltage_node", testNodeConfig(map[string]any{"wait_for_status": "ready"}))
+	if !hasErrors(diags) {
+		t.Error("unknown wait_for_status is accepted")
+	}
+}
+
+func TestNodeResourceNoWait(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(map[string]any{"wait_for_ready": false})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+
+	node := nodeState(t, state)
+	if got := node.Status.ValueString(); got != "waiting_init" {
+		t.Errorf("status = %q, want the one of the just created node", got)
+	}
+	if !node.InitDuration.IsNull() {
+		t.Errorf("init_duration_seconds = %s, want null without waiting", node.InitDuration)
+	}
+	// The node is read once, without checking its status.
+	if got := p.api.Calls("/node"); got != 1 {
+		t.Errorf("called /node %d times, want 1", got)
+	}
+
+	diags = p.validate("voltage_node", testNodeConfig(map[string]any{"wait_for_ready": false, "wait_for_status": "running"}))
+	requireNoErrors(t, diags)
+	if findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "wait_for_status is ignored") == nil {
+		t.Errorf("an ignored wait_for_status didn't warn, got %v", summaries(diags))
+	}
+}
+
+func TestNodeResourceWhitelistRejectedWithoutEntry(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+
+	// The API doesn't tell which entry it rejects.
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/node/whitelist" {
+			writeJSON(w, http.StatusBadRequest, `{"message": "whitelist is invalid"}`)
+
+			return
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(rejecting.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": rejecting.URL}))
+
+	config["settings"] = testSettings(map[string]any{"whitelist": []any{"1.2.3.4", "192.168.0.1"}})
+	_, diags = p.apply("voltage_node", state, config)
+	if !hasErrors(diags) {
+		t.Fatal("a rejected whitelist was applied")
+	}
+	if findDiag(diags, tfprotov6.DiagnosticSeverityError, "Invalid whitelist entry") != nil {
+		t.Error("an entry was pointed at while the API didn't name any")
+	}
+	if d := findDiag(diags, tfprotov6.DiagnosticSeverityError, "POST /node/whitelist"); d == nil || !strings.Contains(d.Detail, "whitelist is invalid") {
+		t.Errorf("got %v, want the API error", summaries(diags))
+	}
+}
+
+func TestNodeResourceIsMainnet(t *testing.T) {
+	for network, want := range map[string]bool{
+		"mainnet": true,
+		"testnet": false,
+	} {
+		t.Run(network, func(t *testing.T) {
+			p := newTestProvider(t, nil)
+			config := testNodeConfig(map[string]any{"network": network})
+			state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+			requireNoErrors(t, diags)
+			if got := nodeState(t, state).IsMainnet; got.IsNull() || got.ValueBool() != want {
+				t.Errorf("is_mainnet after create = %s, want %t", got, want)
+			}
+
+			state, diags = p.read("voltage_node", state)
+			requireNoErrors(t, diags)
+			if got := nodeState(t, state).IsMainnet.ValueBool(); got != want {
+				t.Errorf("is_mainnet after refresh = %t, want %t", got, want)
+			}
+
+			imported, diags := p.importState("voltage_node", nodeState(t, state).NodeID.ValueString())
+			requireNoErrors(t, diags)
+			imported, diags = p.read("voltage_node", imported)
+			requireNoErrors(t, diags)
+			if got := nodeState(t, imported).IsMainnet.ValueBool(); got != want {
+				t.Errorf("is_mainnet after import = %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestNodeResourceColorNormalization(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	// Lowercase the colors set, and leave the rest to the fake API.
+	normalizing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/node/settings" {
+			var body voltage.PostNodeSettingsJSONRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			if body.Settings.Color != nil {
+				body.Settings.Color = toPtr(strings.ToLower(*body.Settings.Color))
+			}
+			b, _ := json.Marshal(body)
+			r.Body = io.NopCloser(bytes.NewReader(b))
+			r.ContentLength = int64(len(b))
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(normalizing.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": normalizing.URL}))
+
+	config := testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"color": "#AABBCC"})})
+	state, diags = p.apply("voltage_node", state, config)
+	requireNoErrors(t, diags)
+	node := nodeState(t, state)
+	if remote, _ := p.api.Node(node.NodeID.ValueString()); *remote.Settings.Color != "#aabbcc" {
+		t.Fatalf("remote color = %s, want it normalized", *remote.Settings.Color)
+	}
+	if got := node.Settings.Color.ValueString(); got != "#AABBCC" {
+		t.Errorf("color = %s, want the configured one", got)
+	}
+
+	state, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	plan, planned := p.plan("voltage_node", state, config)
+	requireNoErrors(t, plan.Diagnostics)
+	if !planned.Equal(state) {
+		t.Errorf("a normalized color plans changes:\n%s\n%s", planned, state)
+	}
+
+	// Another color is still a change.
+	config = testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"color": "#AABBCD"})})
+	if _, planned = p.plan("voltage_node", state, config); planned.Equal(state) {
+		t.Error("changing the color plans no changes")
+	}
+}
+
+func TestNodeResourceValidateConfig(t *testing.T) {
+	settings := tftypes.NewAttributePath().WithAttributeName("settings")
+	for name, tc := range map[string]struct {
+		config    map[string]any
+		severity  tfprotov6.DiagnosticSeverity
+		summary   string
+		attribute *tftypes.AttributePath
+	}{
+		"zeroconf with scid alias": {
+			config: testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"zeroconf": true, "optionscidalias": true})}),
+		},
+		"zeroconf without scid alias": {
+			config:    testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"zeroconf": true})}),
+			severity:  tfprotov6.DiagnosticSeverityError,
+			summary:   "Invalid zeroconf setting",
+			attribute: settings.WithAttributeName("zeroconf"),
+		},
+		"zeroconf with unknown scid alias": {
+			config: testNodeConfig(map[string]any{"settings": testSettings(map[string]any{
+				"zeroconf":        true,
+				"optionscidalias": tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue),
+			})}),
+		},
+		"channel sizes in order": {
+			config: testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"minchansize": "20000", "maxchansize": "20000"})}),
+		},
+		"channel sizes swapped": {
+			config:    testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"minchansize": "50000", "maxchansize": "20000"})}),
+			severity:  tfprotov6.DiagnosticSeverityError,
+			summary:   "Invalid channel size limits",
+			attribute: settings.WithAttributeName("maxchansize"),
+		},
+		"waiting for a status": {
+			config: testNodeConfig(map[string]any{"wait_for_status": "running"}),
+		},
+		"not waiting for a status": {
+			config:    testNodeConfig(map[string]any{"wait_for_ready": false, "wait_for_status": "running"}),
+			severity:  tfprotov6.DiagnosticSeverityWarning,
+			summary:   "wait_for_status is ignored",
+			attribute: tftypes.NewAttributePath().WithAttributeName("wait_for_status"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			diags := newTestProvider(t, nil).validate("voltage_node", tc.config)
+			if tc.summary == "" {
+				requireNoErrors(t, diags)
+				if len(diags) > 0 {
+					t.Errorf("got %v, want no diagnostics", summaries(diags))
+				}
+
+				return
+			}
+
+			d := findDiag(diags, tc.severity, tc.summary)
+			if d == nil {
+				t.Fatalf("got %v, want %q", summaries(diags), tc.summary)
+			}
+			if !d.Attribute.Equal(tc.attribute) {
+				t.Errorf("attribute = %s, want %s", d.Attribute, tc.attribute)
+			}
+		})
+	}
+}
+
+func TestNodeResourceSettingsDriftWarning(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	nodeID := nodeState(t, state).NodeID.ValueString()
+
+	// Settings that can be updated in place don't warn.
+	p.api.UpdateNode(nodeID, func(n *voltage.NodeDocument) {
+		n.Settings.Alias = toPtr("renamed")
+	})
+	_, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	if findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Node settings changed outside of Terraform") != nil {
+		t.Error("a changed alias warned")
+	}
+
+	p.api.UpdateNode(nodeID, func(n *voltage.NodeDocument) {
+		n.Settings.Wumbo = toPtr(true)
+	})
+	refreshed, diags := p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	d := findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Node settings changed outside of Terraform")
+	if d == nil || !strings.Contains(d.Detail, "wumbo") || strings.Contains(d.Detail, "alias") {
+		t.Fatalf("got %v, want a warning about wumbo only", summaries(diags))
+	}
+	if !d.Attribute.Equal(tftypes.NewAttributePath().WithAttributeName("settings")) {
+		t.Errorf("warning attribute = %s, want settings", d.Attribute)
+	}
+	// The state keeps the configured value, so that no update is planned.
+	if nodeState(t, refreshed).Settings.Wumbo.ValueBool() {
+		t.Error("wumbo was refreshed from the API")
+	}
+}
+
+func TestNodeResourceNameTakenWarning(t *testing.T) {
+	p := newTestProvider(t, map[string]any{"check_node_names": true})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	for name, tc := range map[string]struct {
+		config   map[string]any
+		wantWarn bool
+	}{
+		"taken":         {config: testNodeConfig(nil), wantWarn: true},
+		"other name":    {config: testNodeConfig(map[string]any{"name": "other-node"})},
+		"other network": {config: testNodeConfig(map[string]any{"network": "mainnet"})},
+		"adopting it":   {config: testNodeConfig(map[string]any{"adopt_existing": true})},
+	} {
+		plan, _ := p.plan("voltage_node", p.config("voltage_node", nil), tc.config)
+		requireNoErrors(t, plan.Diagnostics)
+		if warned := findDiag(plan.Diagnostics, tfprotov6.DiagnosticSeverityWarning, "Node name already taken") != nil; warned != tc.wantWarn {
+			t.Errorf("%s: warned = %t, want %t", name, warned, tc.wantWarn)
+		}
+	}
+
+	// The name is free again once the node is deleted.
+	_, diags = p.apply("voltage_node", state, nil)
+	requireNoErrors(t, diags)
+	plan, _ := p.plan("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	if findDiag(plan.Diagnostics, tfprotov6.DiagnosticSeverityWarning, "Node name already taken") != nil {
+		t.Error("the name of a deleted node is reported as taken")
+	}
+
+	// Names aren't checked by default.
+	p = newTestProvider(t, nil)
+	plan, _ = p.plan("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, plan.Diagnostics)
+	if got := p.api.Calls("/node/name"); got != 0 {
+		t.Errorf("/node/name called %d times without check_node_names, want 0", got)
+	}
+}
+
+func TestNodeResourceComputedNotReady(t *testing.T) {
+	p := startTestProvider(t)
+
+	// Voltage doesn't report the endpoint of the node yet.
+	var mu sync.Mutex
+	ready := false
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		strip := !ready && r.URL.Path == "/node" && r.Method == http.MethodPost
+		mu.Unlock()
+		if !strip {
+			p.api.Config.Handler.ServeHTTP(w, r)
+
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		p.api.Config.Handler.ServeHTTP(rec, r)
+		var node map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &node); err != nil {
+			t.Error(err)
+		}
+		delete(node, "api_endpoint")
+		b, _ := json.Marshal(node)
+		writeJSON(w, rec.Code, string(b))
+	}))
+	t.Cleanup(api.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": api.URL}))
+
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	d := findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Some node attributes aren't available yet")
+	if d == nil || !strings.Contains(d.Detail, "api_endpoint") || strings.Contains(d.Detail, "running_lnd_version") {
+		t.Errorf("got %v, want a warning about api_endpoint only", summaries(diags))
+	}
+	if node := nodeState(t, state); !node.APIEndpoint.IsNull() || node.RunningLndVersion.IsNull() {
+		t.Errorf("api_endpoint = %s, running_lnd_version = %s, want only api_endpoint null",
+			node.APIEndpoint, node.RunningLndVersion)
+	}
+
+	// The next refresh populates it.
+	mu.Lock()
+	ready = true
+	mu.Unlock()
+	state, diags = p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	if nodeState(t, state).APIEndpoint.IsNull() {
+		t.Error("api_endpoint is still null after a refresh")
+	}
+}
+
+func TestNodeModelCreateRequest(t *testing.T) {
+	settings := &nodeSettingsModel{}
+	settings.setFromAPI(&voltage.NodeSettings{Alias: toPtr("node"), Whitelist: &[]string{"010.0.0.1"}})
+	m := nodeModel{
+		Name:          types.StringValue("node"),
+		Network:       types.StringValue("testnet"),
+		PurchasedType: types.StringValue("paid"),
+		Type:          types.StringValue("standard"),
+		Settings:      settings,
+	}
+
+	body, err := m.createRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Name != "node" || body.Network != "testnet" || body.PurchasedType != "paid" || body.Type != "standard" {
+		t.Errorf("create request = %+v, want the node of the model", body)
+	}
+	if *body.Settings.Alias != "node" || !reflect.DeepEqual(*body.Settings.Whitelist, []string{"10.0.0.1"}) {
+		t.Errorf("create request settings = %+v, want the normalized settings of the model", body.Settings)
+	}
+
+	settings.Whitelist = []types.String{types.StringNull()}
+	if _, err := m.createRequest(); err == nil {
+		t.Error("a create request with a null whitelist entry was built")
+	}
+}
+
+func TestNodeResourceInitDuration(t *testing.T) {
+	p := startTestProvider(t)
+
+	// The node only shows up in the list a second after its creation.
+	var mu sync.Mutex
+	var created time.Time
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.URL.Path == "/node/create" {
+			created = time.Now()
+		}
+		ready := created.Add(1100 * time.Millisecond)
+		mu.Unlock()
+		if r.URL.Path == "/node" && r.Method == http.MethodGet {
+			time.Sleep(time.Until(ready))
+		}
+		p.api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(api.Close)
+	requireNoErrors(t, p.configure(map[string]any{"host": api.URL}))
+
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	if got := nodeState(t, state).InitDuration; got.IsNull() || got.ValueInt64() < 1 || got.ValueInt64() > 2 {
+		t.Errorf("init_duration_seconds = %s, want about 1", got)
+	}
+
+	// Refreshing keeps it.
+	refreshed, diags := p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	if got, want := nodeState(t, refreshed).InitDuration, nodeState(t, state).InitDuration; !got.Equal(want) {
+		t.Errorf("init_duration_seconds after refresh = %s, want %s", got, want)
+	}
+}
+
+func TestNodeResourcePlanKeepsStableComputed(t *testing.T) {
+	p := newTestProvider(t, nil)
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+	prior := nodeState(t, state)
+
+	for test, config := range map[string]map[string]any{
+		"no-op":        testNodeConfig(nil),
+		"alias update": testNodeConfig(map[string]any{"settings": testSettings(map[string]any{"alias": "renamed"})}),
+	} {
+		plan, planned := p.plan("voltage_node", state, config)
+		requireNoErrors(t, plan.Diagnostics)
+
+		node := nodeState(t, planned)
+		for name, v := range map[string][2]attr.Value{
+			"node_id":      {node.NodeID, prior.NodeID},
+			"created":      {node.Created, prior.Created},
+			"api_endpoint": {node.APIEndpoint, prior.APIEndpoint},
+			"is_mainnet":   {node.IsMainnet, prior.IsMainnet},
+		} {
+			if v[0].IsUnknown() || !v[0].Equal(v[1]) {
+				t.Errorf("%s: planned %s = %s, want %s from the state", test, name, v[0], v[1])
+			}
+		}
+	}
+}
+
+func TestNodeResourceReconcileReplace(t *testing.T) {
+	p := newTestProvider(t, nil)
+	config := testNodeConfig(map[string]any{"reconcile": "replace"})
+	state, diags := p.apply("voltage_node", p.config("voltage_node", nil), config)
+	requireNoErrors(t, diags)
+
+	// Settings that can be updated in place are still updated in place.
+	plan, _ := p.plan("voltage_node", state, testNodeConfig(map[string]any{
+		"reconcile": "replace",
+		"settings":  testSettings(map[string]any{"alias": "renamed"}),
+	}))
+	requireNoErrors(t, plan.Diagnostics)
+	if len(plan.RequiresReplace) > 0 {
+		t.Errorf("changing the alias plans to replace the node: %v", plan.RequiresReplace)
+	}
+
+	p.api.UpdateNode(nodeState(t, state).NodeID.ValueString(), func(n *voltage.NodeDocument) {
+		n.Settings.Wumbo = toPtr(true)
+	})
+	refreshed, diags := p.read("voltage_node", state)
+	requireNoErrors(t, diags)
+	if findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "Node settings changed outside of Terraform") != nil {
+		t.Error("drift warned instead of being refreshed")
+	}
+	if !nodeState(t, refreshed).Settings.Wumbo.ValueBool() {
+		t.Fatal("wumbo was not refreshed from the API")
+	}
+
+	plan, _ = p.plan("voltage_node", refreshed, config)
+	requireNoErrors(t, plan.Diagnostics)
+	want := tftypes.NewAttributePath().WithAttributeName("settings")
+	var replaced bool
+	for _, path := range plan.RequiresReplace {
+		replaced = replaced || path.Equal(want)
+	}
+	if !replaced {
+		t.Errorf("drift planned replacing %v, want settings", plan.RequiresReplace)
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+var nodeSettingsDataSourceSchema = schema.Schema{
+	Description: "Validates and normalizes node settings without a node, so that the same settings can be shared " +
+		"by several voltage_node, e.g. settings = data.voltage_node_settings.standard.settings",
+	Attributes: map[string]schema.Attribute{
+		"input": schema.SingleNestedAttribute{
+			Description: "The settings to validate, they take the same attributes as the voltage_node settings",
+			Required:    true,
+			Attributes:  settingsAttributes[schema.Attribute](false),
+		},
+		"settings": schema.SingleNestedAttribute{
+			Description: "The input with the defaults applied and the whitelist normalized",
+			Computed:    true,
+			Attributes:  settingsAttributes[schema.Attribute](true),
+		},
+	},
+}
+
+type nodeSettingsDataSourceModel struct {
+	Input    nodeSettingsModel  `tfsdk:"input"`
+	Settings *nodeSettingsModel `tfsdk:"settings"`
+}
+
+type NodeSettingsDataSource struct{}
+
+func NewNodeSettingsDataSource() datasource.DataSource {
+	return &NodeSettingsDataSource{}
+}
+
+func (d *NodeSettingsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_node_settings"
+}
+
+func (d *NodeSettingsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = nodeSettingsDataSourceSchema
+}
+
+// ValidateConfig checks the same rules spanning several settings as
+// voltage_node.
+func (d *NodeSettingsDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateSettingsConfig(ctx, req.Config, path.Root("input"))...)
+}
+
+func (d *NodeSettingsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state nodeSettingsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Go through the API representation, as voltage_node would when
+	// creating the node.
+	input := state.Input
+	input.setDefaults(ctx)
+	settings, err := input.toAPI()
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("input").AtName("whitelist"), "Invalid whitelist", err.Error())
+
+		return
+	}
+	state.Settings = &nodeSettingsModel{}
+	state.Settings.setFromAPI(&settings)
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+func TestNodeSettingsDataSourceRead(t *testing.T) {
+	p := newTestProvider(t, nil)
+	v, diags := p.readDataSource("voltage_node_settings", map[string]any{
+		"input": testSettings(map[string]any{"whitelist": []any{"010.000.000.001", "10.0.0.1", "::1/128"}}),
+	})
+	requireNoErrors(t, diags)
+
+	var got nodeSettingsDataSourceModel
+	if diags := (tfsdk.State{Schema: nodeSettingsDataSourceSchema, Raw: v}).Get(context.Background(), &got); diags.HasError() {
+		t.Fatalf("decoding data source state: %v", diags)
+	}
+	if got.Settings == nil {
+		t.Fatal("settings are null")
+	}
+	if want := []types.String{types.StringValue("10.0.0.1"), types.StringValue("::1/128")}; !equalStrings(got.Settings.Whitelist, want) {
+		t.Errorf("whitelist = %v, want %v", got.Settings.Whitelist, want)
+	}
+	if got.Settings.Alias.ValueString() != "test-node" || got.Settings.Wumbo.IsNull() {
+		t.Errorf("settings = %+v, want the input with the defaults applied", got.Settings)
+	}
+}
+
+func TestNodeSettingsDataSourceValidatesInput(t *testing.T) {
+	p := newTestProvider(t, nil)
+	for name, tc := range map[string]struct {
+		settings map[string]any
+		summary  string
+	}{
+		"validator": {
+			settings: map[string]any{"defaultfeerate": "200000"},
+			summary:  "Invalid value",
+		},
+		"cross attribute rule": {
+			settings: map[string]any{"zeroconf": true, "optionscidalias": false},
+			summary:  "Invalid zeroconf setting",
+		},
+		"missing required setting": {
+			settings: map[string]any{"alias": nil},
+			summary:  "Missing Configuration for Required Attribute",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, diags := p.readDataSource("voltage_node_settings", map[string]any{"input": testSettings(tc.settings)})
+			if findDiag(diags, tfprotov6.DiagnosticSeverityError, tc.summary) == nil {
+				t.Errorf("got %v, want a %q error", summaries(diags), tc.summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// nodeStatuses are all the statuses Voltage reports for a node.
+var nodeStatuses = []string{
+	"provisioning", "waiting_init", "waiting_unlock", "starting", "running", "stopping", "stopped",
+	"restarting", "deleted",
+}
+
+var nodesDataSourceSchema = schema.Schema{
+	Description: "Lists the nodes in the Voltage account, optionally filtered by status",
+	Attributes: map[string]schema.Attribute{
+		"status": schema.StringAttribute{
+			Description: "Only list the nodes with this status",
+			Optional:    true,
+			Validators: []validator.String{
+				stringvalidator.OneOf(nodeStatuses...),
+			},
+		},
+		"total": schema.Int64Attribute{
+			Description: "Number of nodes listed",
+			Computed:    true,
+		},
+		"nodes": schema.ListNestedAttribute{
+			Description: "The nodes listed",
+			Computed:    true,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"node_id": schema.StringAttribute{
+						Description: "Unique ID for the node",
+						Computed:    true,
+					},
+					"name": schema.StringAttribute{
+						Description: "User defined node name given at creation",
+						Computed:    true,
+					},
+					"network": schema.StringAttribute{
+						Description: "Network the node is running on",
+						Computed:    true,
+					},
+					"purchased_type": schema.StringAttribute{
+						Description: "Purchase type of the node",
+						Computed:    true,
+					},
+					"type": schema.StringAttribute{
+						Description: "Type of node",
+						Computed:    true,
+					},
+					"status": schema.StringAttribute{
+						Description: "Status of the node",
+						Computed:    true,
+					},
+					"created": schema.StringAttribute{
+						Description: "Date that the node was created",
+						Computed:    true,
+					},
+					"expires": schema.StringAttribute{
+						Description: "Date that the node expires",
+						Computed:    true,
+					},
+					"api_endpoint": schema.StringAttribute{
+						Description: "API Endpoint for the node",
+						Computed:    true,
+					},
+					"lnd_version": schema.StringAttribute{
+						Description: "Version of LND the node is running",
+						Computed:    true,
+					},
+				},
+			},
+		},
+	},
+}
+
+type nodesDataSourceModel struct {
+	Status types.String          `tfsdk:"status"`
+	Total  types.Int64           `tfsdk:"total"`
+	Nodes  []nodesDataSourceNode `tfsdk:"nodes"`
+}
+
+type nodesDataSourceNode struct {
+	NodeID        types.String `tfsdk:"node_id"`
+	Name          types.String `tfsdk:"name"`
+	Network       types.String `tfsdk:"network"`
+	PurchasedType types.String `tfsdk:"purchased_type"`
+	Type          types.String `tfsdk:"type"`
+	Status        types.String `tfsdk:"status"`
+	Created       types.String `tfsdk:"created"`
+	Expires       types.String `tfsdk:"expires"`
+	APIEndpoint   types.String `tfsdk:"api_endpoint"`
+	LndVersion    types.String `tfsdk:"lnd_version"`
+}
+
+type NodesDataSource struct {
+	client *Client
+}
+
+func NewNodesDataSource() datasource.DataSource {
+	return &NodesDataSource{}
+}
+
+func (d *NodesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_nodes"
+}
+
+func (d *NodesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = nodesDataSourceSchema
+}
+
+func (d *NodesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = data.client
+}
+
+func (d *NodesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state nodesDataSourceModel
+
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	nodes, err := d.client.ListNodes(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	// The API can't filter, do it here.
+	want := state.Status.ValueString()
+	state.Nodes = []nodesDataSourceNode{}
+	for _, n := range nodes {
+		if want != "" && (n.Status == nil || *n.Status != want) {
+			continue
+		}
+
+		state.Nodes = append(state.Nodes, nodesDataSourceNode{
+			NodeID:        types.StringPointerValue(n.NodeId),
+			Name:          types.StringPointerValue(n.NodeName),
+			Network:       types.StringPointerValue(n.Network),
+			PurchasedType: types.StringPointerValue(n.PurchasedType),
+			Type:          types.StringPointerValue(n.Type),
+			Status:        types.StringPointerValue(n.Status),
+			Created:       types.StringPointerValue(n.Created),
+			Expires:       types.StringPointerValue(n.Expires),
+			APIEndpoint:   types.StringPointerValue(n.ApiEndpoint),
+			LndVersion:    types.StringPointerValue(n.LndVersion),
+		})
+	}
+	state.Total = types.Int64Value(int64(len(state.Nodes)))
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
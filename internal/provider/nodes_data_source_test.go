@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
+)
+
+func TestNodesDataSourceStatusFilter(t *testing.T) {
+	p := newTestProvider(t, nil)
+	var ids []string
+	for _, name := range []string{"node-a", "node-b"} {
+		state, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(map[string]any{"name": name}))
+		requireNoErrors(t, diags)
+		ids = append(ids, nodeState(t, state).NodeID.ValueString())
+	}
+	sort.Strings(ids)
+	for i, status := range []string{"stopped", "running"} {
+		p.api.UpdateNode(ids[i], func(n *voltage.NodeDocument) {
+			n.Status = toPtr(status)
+		})
+	}
+
+	for status, want := range map[string][]string{
+		"":             ids,
+		"stopped":      ids[:1],
+		"running":      ids[1:],
+		"waiting_init": nil,
+	} {
+		config := map[string]any{}
+		if status != "" {
+			config["status"] = status
+		}
+		v, diags := p.readDataSource("voltage_nodes", config)
+		requireNoErrors(t, diags)
+
+		var got nodesDataSourceModel
+		if diags := (tfsdk.State{Schema: nodesDataSourceSchema, Raw: v}).Get(context.Background(), &got); diags.HasError() {
+			t.Fatalf("decoding data source state: %v", diags)
+		}
+		var gotIDs []string
+		for _, n := range got.Nodes {
+			if status != "" && n.Status.ValueString() != status {
+				t.Errorf("status %q: listed node %s with status %s", status, n.NodeID, n.Status)
+			}
+			gotIDs = append(gotIDs, n.NodeID.ValueString())
+		}
+		// The API does not promise any order.
+		sort.Strings(gotIDs)
+		if !reflect.DeepEqual(gotIDs, want) || got.Total.ValueInt64() != int64(len(want)) {
+			t.Errorf("status %q: listed %v (total %s), want %v", status, gotIDs, got.Total, want)
+		}
+	}
+
+	if _, diags := p.readDataSource("voltage_nodes", map[string]any{"status": "ready"}); !hasErrors(diags) {
+		t.Error("unknown status is accepted")
+	}
+}
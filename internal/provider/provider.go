@@ -2,23 +2,65 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/qustavo/terraform-provider-voltage/internal/voltage"
 )
 
 const (
 	voltageHost = "https://api.voltage.cloud"
+
+	defaultAPIVersion = "v1"
+
+	authHeader = "X-VOLTAGE-AUTH"
 )
 
+// authValue returns the value of the authentication header carrying token,
+// prefixed by scheme, e.g. Bearer, if any.
+func authValue(scheme, token string) string {
+	if scheme == "" {
+		return token
+	}
+
+	return scheme + " " + token
+}
+
+// headerNameRegexp matches valid HTTP header names (RFC 7230 tokens).
+var headerNameRegexp = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// apiBasePaths maps every supported API version to the path, relative to
+// the host, it is served from.
+var apiBasePaths = map[string]string{
+	"v1": "",
+}
+
+// apiVersions returns the supported API versions, sorted.
+func apiVersions() []string {
+	versions := make([]string, 0, len(apiBasePaths))
+	for v := range apiBasePaths {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	return versions
+}
+
 func New(version string) func() provider.Provider {
 	return func() provider.Provider {
 		return &voltageProvider{
@@ -44,13 +86,180 @@ func (p *voltageProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"host": schema.StringAttribute{
+				Description: "Voltage API host. Defaults to " + voltageHost + ". May also be provided via the VOLTAGE_HOST environment variable.",
+				Optional:    true,
+			},
+			"api_version": schema.StringAttribute{
+				Description: "Voltage API version to use. Defaults to " + defaultAPIVersion + ".",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(apiVersions()...),
+				},
+			},
+			"ca_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM encoded CA bundle used to verify the API host certificate",
+				Optional:    true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				Description: "Skip the verification of the API host certificate. Only meant for local testing " +
+					"against a self-signed host, never enable it in production.",
+				Optional: true,
+			},
+			"debug_http": schema.BoolAttribute{
+				Description: "Log API request and response bodies at debug level, with secrets scrubbed",
+				Optional:    true,
+			},
+			"verify_on_configure": schema.BoolAttribute{
+				Description: "Check that the API is reachable and the token is valid when the provider is configured, " +
+					"instead of failing on the first node operation.",
+				Optional: true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Description: "Maximum number of in-flight API requests shared by all resources. Unlimited by default.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"check_node_names": schema.BoolAttribute{
+				Description: "Warn at plan time when the name of a new node is already taken. " +
+					"Disabled by default as it costs an API call per planned node.",
+				Optional: true,
+			},
+			"expose_raw_response": schema.BoolAttribute{
+				Description: "Keep the node as returned by the API in the raw_json attribute of voltage_node, " +
+					"with secrets scrubbed. Useful for bug reports, disabled by default to keep the state small.",
+				Optional: true,
+			},
+			"poll_interval": schema.StringAttribute{
+				Description: "How long to wait before the first status check of a node, e.g. 500ms. Later checks " +
+					"are spaced out by half more each time, up to max_poll_interval. Defaults to 1s.",
+				Optional: true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"max_poll_interval": schema.StringAttribute{
+				Description: "Longest wait between two status checks of a node, e.g. 30s. Defaults to 5s, " +
+					"raised to poll_interval if lower.",
+				Optional: true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"max_poll_attempts": schema.Int64Attribute{
+				Description: "Maximum number of status checks made while waiting for a node, on top of the time " +
+					"based timeouts. Unlimited by default.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"max_retries": schema.Int64Attribute{
+				Description: "How many times an API call failing with no response or a 429 or 5xx status is sent " +
+					"again. Node creations are never retried. Defaults to 0.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_max_backoff": schema.StringAttribute{
+				Description: "Longest wait before retrying a failed node status check, e.g. 10s. " +
+					"By default it grows like the wait between checks.",
+				Optional: true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"retry_max_elapsed": schema.StringAttribute{
+				Description: "How long node status checks can keep failing before giving up, e.g. 5m. " +
+					"By default they are retried until the operation times out.",
+				Optional: true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "How long a single API call can take before failing, e.g. 10s. Defaults to 30s.",
+				Optional:    true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"default_tags": schema.MapAttribute{
+				Description: "Tags applied to every node, merged into their tags_all. Node tags take precedence.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"auth_header": schema.StringAttribute{
+				Description: "Name of the header carrying the API token. Defaults to " + authHeader + ".",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(headerNameRegexp, "must be a valid HTTP header name"),
+				},
+			},
+			"auth_scheme": schema.StringAttribute{
+				Description: "Scheme prefixed to the API token, e.g. Bearer together with auth_header = \"Authorization\". " +
+					"The token is sent alone by default.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(headerNameRegexp, "must be a single word such as Bearer"),
+				},
+			},
+			"extra_headers": schema.MapAttribute{
+				Description: "Additional HTTP headers sent with every API request, e.g. for proxies. " +
+					"They can't override the authentication header.",
+				Optional:    true,
+				ElementType: types.StringType,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(
+						stringvalidator.RegexMatches(headerNameRegexp, "must be a valid HTTP header name"),
+					),
+				},
+			},
 		},
 	}
 
 }
 
 type voltageProviderModel struct {
-	Token types.String `tfsdk:"token"`
+	Token      types.String `tfsdk:"token"`
+	Host       types.String `tfsdk:"host"`
+	APIVersion types.String `tfsdk:"api_version"`
+	CACertFile types.String `tfsdk:"ca_cert_file"`
+	DebugHTTP  types.Bool   `tfsdk:"debug_http"`
+	Insecure   types.Bool   `tfsdk:"insecure_skip_verify"`
+	Verify     types.Bool   `tfsdk:"verify_on_configure"`
+
+	MaxConcurrentRequests types.Int64       `tfsdk:"max_concurrent_requests"`
+	PollInterval          types.String      `tfsdk:"poll_interval"`
+	MaxPollInterval       types.String      `tfsdk:"max_poll_interval"`
+	MaxPollAttempts       types.Int64       `tfsdk:"max_poll_attempts"`
+	MaxRetries            types.Int64       `tfsdk:"max_retries"`
+	RetryMaxBackoff       types.String      `tfsdk:"retry_max_backoff"`
+	RetryMaxElapsed       types.String      `tfsdk:"retry_max_elapsed"`
+	RequestTimeout        types.String      `tfsdk:"request_timeout"`
+	DefaultTags           map[string]string `tfsdk:"default_tags"`
+	CheckNodeNames        types.Bool        `tfsdk:"check_node_names"`
+	ExposeRawResponse     types.Bool        `tfsdk:"expose_raw_response"`
+	AuthHeader            types.String      `tfsdk:"auth_header"`
+	AuthScheme            types.String      `tfsdk:"auth_scheme"`
+	ExtraHeaders          map[string]string `tfsdk:"extra_headers"`
+}
+
+// providerData is passed to the resources and data sources when the provider
+// is configured.
+type providerData struct {
+	client *Client
+	// defaultTags are merged into the tags of every node.
+	defaultTags map[string]string
+	// checkNodeNames makes the node resource check at plan time whether the
+	// name of a new node is already taken.
+	checkNodeNames bool
+	// exposeRawResponse makes the node resource keep the node as returned
+	// by the API in its state.
+	exposeRawResponse bool
 }
 
 func (p *voltageProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
@@ -64,7 +273,6 @@ func (p *voltageProvider) Configure(ctx context.Context, req provider.ConfigureR
 	}
 
 	token := os.Getenv("VOLTAGE_TOKEN")
-	tflog.Warn(ctx, "got token", map[string]any{"token": token})
 
 	if !config.Token.IsNull() {
 		token = config.Token.ValueString()
@@ -80,17 +288,77 @@ func (p *voltageProvider) Configure(ctx context.Context, req provider.ConfigureR
 		)
 	}
 
+	host := os.Getenv("VOLTAGE_HOST")
+	if !config.Host.IsNull() {
+		host = config.Host.ValueString()
+	}
+	if host == "" {
+		host = voltageHost
+	}
+
+	apiVersion := defaultAPIVersion
+	if !config.APIVersion.IsNull() {
+		apiVersion = config.APIVersion.ValueString()
+	}
+	server := strings.TrimSuffix(host, "/") + apiBasePaths[apiVersion]
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if config.Insecure.ValueBool() {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("insecure_skip_verify"),
+			"TLS verification is disabled",
+			"The API host certificate won't be verified, anyone able to intercept the connection can "+
+				"steal the API token. Only use insecure_skip_verify for local testing.",
+		)
+	}
+
+	httpClient, err := newHTTPClient(
+		config.CACertFile.ValueString(), config.Insecure.ValueBool(), config.DebugHTTP.ValueBool(),
+	)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("ca_cert_file"),
+			"Invalid CA certificate file",
+			err.Error(),
+		)
+
+		return
+	}
+
+	header := authHeader
+	if !config.AuthHeader.IsNull() {
+		header = config.AuthHeader.ValueString()
+	}
+	scheme := config.AuthScheme.ValueString()
+	for k := range config.ExtraHeaders {
+		if strings.EqualFold(k, header) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("extra_headers"),
+				"Invalid extra header",
+				fmt.Sprintf("%s carries the API token and can't be set as an extra header.", k),
+			)
+
+			return
+		}
+	}
+
 	requestEditorFn := func(_ context.Context, req *http.Request) error {
-		req.Header.Set("X-VOLTAGE-AUTH", token)
+		for k, v := range config.ExtraHeaders {
+			req.Header.Set(k, v)
+		}
+		// Set last so that no extra header can replace it.
+		req.Header.Set(header, authValue(scheme, token))
 
 		return nil
 	}
 
-	client, err := voltage.NewClientWithResponses(voltageHost, voltage.WithRequestEditorFn(requestEditorFn))
+	client, err := voltage.NewClientWithResponses(server,
+		voltage.WithHTTPClient(httpClient),
+		voltage.WithRequestEditorFn(requestEditorFn),
+	)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Could not start a new Voltage API client",
@@ -100,15 +368,64 @@ func (p *voltageProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
-	resp.ResourceData = client
+	// The validator already rejected invalid durations, null ones are zero.
+	pollInterval, _ := time.ParseDuration(config.PollInterval.ValueString())
+	maxPollInterval, _ := time.ParseDuration(config.MaxPollInterval.ValueString())
+	retryMaxBackoff, _ := time.ParseDuration(config.RetryMaxBackoff.ValueString())
+	retryMaxElapsed, _ := time.ParseDuration(config.RetryMaxElapsed.ValueString())
+	requestTimeout, _ := time.ParseDuration(config.RequestTimeout.ValueString())
+
+	// Resources and data sources share the same Client so that they
+	// cooperate on the concurrency limit.
+	c := NewClient(client, ClientConfig{
+		MaxConcurrentRequests: config.MaxConcurrentRequests.ValueInt64(),
+		PollInterval:          pollInterval,
+		MaxPollInterval:       maxPollInterval,
+		MaxPollAttempts:       int(config.MaxPollAttempts.ValueInt64()),
+		MaxRetries:            int(config.MaxRetries.ValueInt64()),
+		RetryMaxBackoff:       retryMaxBackoff,
+		RetryMaxElapsed:       retryMaxElapsed,
+		RequestTimeout:        requestTimeout,
+		AuthHeader:            header,
+		AuthScheme:            scheme,
+	})
+
+	if config.Verify.ValueBool() {
+		if _, err := c.GetUser(ctx); err != nil {
+			resp.Diagnostics.AddError(
+				"Could not reach the Voltage API",
+				fmt.Sprintf("Checking the API host %s and token failed: %s", host, err),
+			)
+
+			return
+		}
+	}
+
+	data := &providerData{
+		client:            c,
+		defaultTags:       config.DefaultTags,
+		checkNodeNames:    config.CheckNodeNames.ValueBool(),
+		exposeRawResponse: config.ExposeRawResponse.ValueBool(),
+	}
+	resp.DataSourceData = data
+	resp.ResourceData = data
 }
 
 func (p *voltageProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewNodeResource,
+		NewNodeReadyResource,
 	}
 }
 
 func (p *voltageProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewNodeDataSource,
+		NewNodesDataSource,
+		NewStatusDataSource,
+		NewCapabilitiesDataSource,
+		NewNodeLogsDataSource,
+		NewNodeSettingsDataSource,
+		NewNodeConnectionDataSource,
+	}
 }
@@ -0,0 +1,649 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/qustavo/terraform-provider-voltage/internal/fakevoltage"
+)
+
+const testToken = "test-token"
+
+// testProvider serves the provider over the plugin protocol, as Terraform
+// does, configured against a fake Voltage API.
+type testProvider struct {
+	t      *testing.T
+	server tfprotov6.ProviderServer
+	api    *fakevoltage.Server
+
+	providerSchema    *tfprotov6.Schema
+	resourceSchemas   map[string]*tfprotov6.Schema
+	dataSourceSchemas map[string]*tfprotov6.Schema
+}
+
+// newTestProvider configures a provider talking to a new fake API with
+// config, on top of the host and token of the fake API.
+func newTestProvider(t *testing.T, config map[string]any) *testProvider {
+	t.Helper()
+
+	p := startTestProvider(t)
+	requireNoErrors(t, p.configure(config))
+
+	return p
+}
+
+// startTestProvider returns a provider for a new fake API, that still has to
+// be configured.
+func startTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	api := fakevoltage.NewServer()
+	api.Token = testToken
+	t.Cleanup(api.Close)
+
+	p := &testProvider{
+		t:      t,
+		server: providerserver.NewProtocol6(New("test")())(),
+		api:    api,
+	}
+
+	schemas, err := p.server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireNoErrors(t, schemas.Diagnostics)
+	p.providerSchema = schemas.Provider
+	p.resourceSchemas = schemas.ResourceSchemas
+	p.dataSourceSchemas = schemas.DataSourceSchemas
+
+	return p
+}
+
+// configure validates config, on top of the host and token of the fake API,
+// and configures the provider with it if valid.
+func (p *testProvider) configure(config map[string]any) []*tfprotov6.Diagnostic {
+	p.t.Helper()
+
+	values := map[string]any{"host": p.api.URL, "token": testToken}
+	for k, v := range config {
+		values[k] = v
+	}
+	dv := p.dynamicValue(p.providerSchema, tfValue(p.t, p.providerSchema.ValueType(), values))
+
+	ctx := context.Background()
+	validated, err := p.server.ValidateProviderConfig(ctx, &tfprotov6.ValidateProviderConfigRequest{Config: dv})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if hasErrors(validated.Diagnostics) {
+		return validated.Diagnostics
+	}
+
+	resp, err := p.server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: dv})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return append(validated.Diagnostics, resp.Diagnostics...)
+}
+
+func (p *testProvider) dynamicValue(schema *tfprotov6.Schema, v tftypes.Value) *tfprotov6.DynamicValue {
+	p.t.Helper()
+
+	dv, err := tfprotov6.NewDynamicValue(schema.ValueType(), v)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return &dv
+}
+
+func (p *testProvider) value(schema *tfprotov6.Schema, dv *tfprotov6.DynamicValue) tftypes.Value {
+	p.t.Helper()
+
+	if dv == nil {
+		return tftypes.NewValue(schema.ValueType(), nil)
+	}
+	v, err := dv.Unmarshal(schema.ValueType())
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return v
+}
+
+// config returns the resource configuration made of values.
+func (p *testProvider) config(typeName string, values map[string]any) tftypes.Value {
+	p.t.Helper()
+
+	if values == nil {
+		return tftypes.NewValue(p.resourceSchemas[typeName].ValueType(), nil)
+	}
+
+	return tfValue(p.t, p.resourceSchemas[typeName].ValueType(), values)
+}
+
+// validate validates the resource configuration made of values.
+func (p *testProvider) validate(typeName string, values map[string]any) []*tfprotov6.Diagnostic {
+	p.t.Helper()
+
+	schema := p.resourceSchemas[typeName]
+	resp, err := p.server.ValidateResourceConfig(context.Background(), &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: typeName,
+		Config:   p.dynamicValue(schema, p.config(typeName, values)),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return resp.Diagnostics
+}
+
+// plan plans the resource going from prior to the configuration made of
+// values, nil values plan its destruction.
+func (p *testProvider) plan(typeName string, prior tftypes.Value, values map[string]any) (*tfprotov6.PlanResourceChangeResponse, tftypes.Value) {
+	p.t.Helper()
+
+	schema := p.resourceSchemas[typeName]
+	config := p.config(typeName, values)
+	resp, err := p.server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       p.dynamicValue(schema, prior),
+		ProposedNewState: p.dynamicValue(schema, proposedNew(schema.Block.Attributes, prior, config)),
+		Config:           p.dynamicValue(schema, config),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return resp, p.value(schema, resp.PlannedState)
+}
+
+// apply plans and applies the resource going from prior to the
+// configuration made of values, nil values destroy it. It fails the test if
+// planning fails, and returns the new state and the apply diagnostics.
+func (p *testProvider) apply(typeName string, prior tftypes.Value, values map[string]any) (tftypes.Value, []*tfprotov6.Diagnostic) {
+	p.t.Helper()
+
+	plan, planned := p.plan(typeName, prior, values)
+	requireNoErrors(p.t, plan.Diagnostics)
+
+	schema := p.resourceSchemas[typeName]
+	resp, err := p.server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       typeName,
+		PriorState:     p.dynamicValue(schema, prior),
+		PlannedState:   p.dynamicValue(schema, planned),
+		Config:         p.dynamicValue(schema, p.config(typeName, values)),
+		PlannedPrivate: plan.PlannedPrivate,
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return p.value(schema, resp.NewState), resp.Diagnostics
+}
+
+// read refreshes the resource state.
+func (p *testProvider) read(typeName string, state tftypes.Value) (tftypes.Value, []*tfprotov6.Diagnostic) {
+	p.t.Helper()
+
+	schema := p.resourceSchemas[typeName]
+	resp, err := p.server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: p.dynamicValue(schema, state),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return p.value(schema, resp.NewState), resp.Diagnostics
+}
+
+// importState imports the resource with id and refreshes it, as terraform
+// import does.
+func (p *testProvider) importState(typeName, id string) (tftypes.Value, []*tfprotov6.Diagnostic) {
+	p.t.Helper()
+
+	schema := p.resourceSchemas[typeName]
+	resp, err := p.server.ImportResourceState(context.Background(), &tfprotov6.ImportResourceStateRequest{
+		TypeName: typeName,
+		ID:       id,
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return tftypes.NewValue(schema.ValueType(), nil), resp.Diagnostics
+	}
+	if len(resp.ImportedResources) != 1 {
+		p.t.Fatalf("imported %d resources, want 1", len(resp.ImportedResources))
+	}
+
+	state, diags := p.read(typeName, p.value(schema, resp.ImportedResources[0].State))
+
+	return state, append(resp.Diagnostics, diags...)
+}
+
+// readDataSource reads the data source configured with values.
+func (p *testProvider) readDataSource(typeName string, values map[string]any) (tftypes.Value, []*tfprotov6.Diagnostic) {
+	p.t.Helper()
+
+	ctx := context.Background()
+	schema := p.dataSourceSchemas[typeName]
+	config := p.dynamicValue(schema, tfValue(p.t, schema.ValueType(), values))
+
+	validated, err := p.server.ValidateDataResourceConfig(ctx, &tfprotov6.ValidateDataResourceConfigRequest{
+		TypeName: typeName,
+		Config:   config,
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if hasErrors(validated.Diagnostics) {
+		return tftypes.NewValue(schema.ValueType(), nil), validated.Diagnostics
+	}
+
+	resp, err := p.server.ReadDataSource(ctx, &tfprotov6.ReadDataSourceRequest{
+		TypeName: typeName,
+		Config:   config,
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return p.value(schema, resp.State), append(validated.Diagnostics, resp.Diagnostics...)
+}
+
+// nodeState decodes a voltage_node state.
+func nodeState(t *testing.T, v tftypes.Value) nodeModel {
+	t.Helper()
+
+	var m nodeModel
+	if diags := (tfsdk.State{Schema: nodeSchemaV1, Raw: v}).Get(context.Background(), &m); diags.HasError() {
+		t.Fatalf("decoding node state: %v", diags)
+	}
+
+	return m
+}
+
+// testNodeConfig returns the configuration of a testnet voltage_node that
+// doesn't wait between status checks, with overrides applied on top.
+func testNodeConfig(overrides map[string]any) map[string]any {
+	config := map[string]any{
+		"name":           "test-node",
+		"network":        "testnet",
+		"purchased_type": "paid",
+		"type":           "standard",
+		"poll_interval":  "1ms",
+		"settings":       testSettings(nil),
+	}
+	for k, v := range overrides {
+		config[k] = v
+	}
+
+	return config
+}
+
+// testSettings returns the required node settings, with overrides applied
+// on top.
+func testSettings(overrides map[string]any) map[string]any {
+	settings := map[string]any{
+		"autopilot": false,
+		"grpc":      true,
+		"rest":      true,
+		"keysend":   true,
+		"whitelist": []any{"1.2.3.4"},
+		"alias":     "test-node",
+		"color":     "#ff9900",
+	}
+	for k, v := range overrides {
+		settings[k] = v
+	}
+
+	return settings
+}
+
+// tfValue converts v to a value of typ. Objects and maps are given as
+// map[string]any, with missing object attributes being null, lists as []any
+// and nil is null.
+func tfValue(t *testing.T, typ tftypes.Type, v any) tftypes.Value {
+	t.Helper()
+
+	if v == nil {
+		return tftypes.NewValue(typ, nil)
+	}
+	if v, ok := v.(tftypes.Value); ok {
+		return v
+	}
+
+	switch typ := typ.(type) {
+	case tftypes.Object:
+		m := v.(map[string]any)
+		for name := range m {
+			if _, ok := typ.AttributeTypes[name]; !ok {
+				t.Fatalf("unknown attribute %q", name)
+			}
+		}
+		attrs := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+		for name, attrType := range typ.AttributeTypes {
+			attrs[name] = tfValue(t, attrType, m[name])
+		}
+
+		return tftypes.NewValue(typ, attrs)
+	case tftypes.Map:
+		elems := make(map[string]tftypes.Value)
+		for k, e := range v.(map[string]any) {
+			elems[k] = tfValue(t, typ.ElementType, e)
+		}
+
+		return tftypes.NewValue(typ, elems)
+	case tftypes.List:
+		var elems []tftypes.Value
+		for _, e := range v.([]any) {
+			elems = append(elems, tfValue(t, typ.ElementType, e))
+		}
+
+		return tftypes.NewValue(typ, elems)
+	default:
+		return tftypes.NewValue(typ, v)
+	}
+}
+
+// proposedNew mimics how Terraform proposes the new state of a resource to
+// plan: the configuration, with the prior value of the computed attributes
+// it leaves null.
+func proposedNew(attrs []*tfprotov6.SchemaAttribute, prior, config tftypes.Value) tftypes.Value {
+	if prior.IsNull() || config.IsNull() || !config.IsKnown() {
+		return config
+	}
+
+	var priorAttrs, configAttrs map[string]tftypes.Value
+	_ = prior.As(&priorAttrs)
+	_ = config.As(&configAttrs)
+
+	proposed := make(map[string]tftypes.Value, len(configAttrs))
+	for _, a := range attrs {
+		p, c := priorAttrs[a.Name], configAttrs[a.Name]
+		switch {
+		case a.Computed && c.IsNull():
+			proposed[a.Name] = p
+		case a.NestedType != nil && a.NestedType.Nesting == tfprotov6.SchemaObjectNestingModeSingle:
+			proposed[a.Name] = proposedNew(a.NestedType.Attributes, p, c)
+		default:
+			proposed[a.Name] = c
+		}
+	}
+
+	return tftypes.NewValue(config.Type(), proposed)
+}
+
+func hasErrors(diags []*tfprotov6.Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func requireNoErrors(t *testing.T, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("unexpected error: %s: %s", d.Summary, d.Detail)
+		}
+	}
+}
+
+// findDiag returns the first diagnostic of severity whose summary contains
+// summary, or nil if there is none.
+func findDiag(diags []*tfprotov6.Diagnostic, severity tfprotov6.DiagnosticSeverity, summary string) *tfprotov6.Diagnostic {
+	for _, d := range diags {
+		if d.Severity == severity && strings.Contains(d.Summary, summary) {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// summaries returns the summaries of diags, for test failure messages.
+func summaries(diags []*tfprotov6.Diagnostic) []string {
+	s := make([]string, 0, len(diags))
+	for _, d := range diags {
+		s = append(s, d.Summary)
+	}
+
+	return s
+}
+
+func TestProviderAPIVersionValidator(t *testing.T) {
+	ctx := context.Background()
+	var resp provider.SchemaResponse
+	New("test")().Schema(ctx, provider.SchemaRequest{}, &resp)
+	validators := resp.Schema.Attributes["api_version"].(schema.StringAttribute).Validators
+
+	validate := func(version string) diag.Diagnostics {
+		var diags diag.Diagnostics
+		for _, v := range validators {
+			var vResp validator.StringResponse
+			v.ValidateString(ctx, validator.StringRequest{
+				Path:        path.Root("api_version"),
+				ConfigValue: types.StringValue(version),
+			}, &vResp)
+			diags.Append(vResp.Diagnostics...)
+		}
+
+		return diags
+	}
+
+	for version := range apiBasePaths {
+		if diags := validate(version); diags.HasError() {
+			t.Errorf("api_version %q is rejected: %v", version, diags)
+		}
+	}
+	if !validate("v0").HasError() {
+		t.Error("unsupported api_version v0 is accepted")
+	}
+}
+
+func TestProviderExtraHeaders(t *testing.T) {
+	var header http.Header
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"user_id": "user-1"}`)
+	}))
+	t.Cleanup(api.Close)
+
+	p := startTestProvider(t)
+	requireNoErrors(t, p.configure(map[string]any{
+		"host":          api.URL,
+		"extra_headers": map[string]any{"X-Route": "eu", "X-Cost-Center": "lightning"},
+	}))
+	_, diags := p.readDataSource("voltage_status", map[string]any{})
+	requireNoErrors(t, diags)
+
+	for k, want := range map[string]string{
+		"X-Route":        "eu",
+		"X-Cost-Center":  "lightning",
+		"X-Voltage-Auth": testToken,
+	} {
+		if got := header.Get(k); got != want {
+			t.Errorf("header %s = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestProviderRequestTimeout(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the client gives up.
+		<-r.Context().Done()
+	}))
+	t.Cleanup(api.Close)
+
+	p := startTestProvider(t)
+	requireNoErrors(t, p.configure(map[string]any{
+		"host":            api.URL,
+		"request_timeout": "20ms",
+	}))
+
+	start := time.Now()
+	_, diags := p.readDataSource("voltage_status", map[string]any{})
+	if !hasErrors(diags) {
+		t.Fatal("reading from a hung API succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %s, want the hung call to time out quickly", elapsed)
+	}
+
+	if !hasErrors(startTestProvider(t).configure(map[string]any{"request_timeout": "soon"})) {
+		t.Error("invalid request_timeout is accepted")
+	}
+}
+
+func TestProviderPollInterval(t *testing.T) {
+	p := newTestProvider(t, nil)
+	node, diags := p.apply("voltage_node", p.config("voltage_node", nil), testNodeConfig(nil))
+	requireNoErrors(t, diags)
+
+	// voltage_node_ready has no poll_interval of its own, it would wait 1s
+	// before its first check without the provider one.
+	requireNoErrors(t, p.configure(map[string]any{"poll_interval": "10ms", "max_poll_interval": "20ms"}))
+	config := map[string]any{"node_id": nodeState(t, node).NodeID.ValueString(), "status": "running"}
+	start := time.Now()
+	_, diags = p.apply("voltage_node_ready", p.config("voltage_node_ready", nil), config)
+	requireNoErrors(t, diags)
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("waited %s, want the provider poll_interval to apply", elapsed)
+	}
+
+	if !hasErrors(startTestProvider(t).configure(map[string]any{"max_poll_interval": "later"})) {
+		t.Error("invalid max_poll_interval is accepted")
+	}
+}
+
+func TestProviderAuthHeader(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config map[string]any
+		header string
+		want   string
+	}{
+		"default":     {header: "X-Voltage-Auth", want: testToken},
+		"custom name": {config: map[string]any{"auth_header": "X-Api-Key"}, header: "X-Api-Key", want: testToken},
+		"bearer": {
+			config: map[string]any{"auth_header": "Authorization", "auth_scheme": "Bearer"},
+			header: "Authorization",
+			want:   "Bearer " + testToken,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var header http.Header
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				writeJSON(w, http.StatusOK, `{"user_id": "user-1"}`)
+			}))
+			t.Cleanup(api.Close)
+
+			p := startTestProvider(t)
+			config := map[string]any{"host": api.URL}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			requireNoErrors(t, p.configure(config))
+			_, diags := p.readDataSource("voltage_status", map[string]any{})
+			requireNoErrors(t, diags)
+
+			if got := header.Get(tc.header); got != tc.want {
+				t.Errorf("header %s = %q, want %q", tc.header, got, tc.want)
+			}
+			if tc.header != "X-Voltage-Auth" && header.Get("X-Voltage-Auth") != "" {
+				t.Error("the token was also sent in X-Voltage-Auth")
+			}
+		})
+	}
+
+	if !hasErrors(startTestProvider(t).configure(map[string]any{"auth_header": "X Api Key"})) {
+		t.Error("invalid auth_header is accepted")
+	}
+}
+
+func TestProviderInvalidExtraHeaders(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config  map[string]any
+		summary string
+	}{
+		"auth header": {
+			config:  map[string]any{"extra_headers": map[string]any{"x-voltage-auth": "other-token"}},
+			summary: "Invalid extra header",
+		},
+		"custom auth header": {
+			config: map[string]any{
+				"auth_header":   "Authorization",
+				"extra_headers": map[string]any{"AUTHORIZATION": "Bearer other-token"},
+			},
+			summary: "Invalid extra header",
+		},
+		"invalid name": {
+			config:  map[string]any{"extra_headers": map[string]any{"X Route": "eu"}},
+			summary: "Invalid Attribute Value Match",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			diags := startTestProvider(t).configure(tc.config)
+			if findDiag(diags, tfprotov6.DiagnosticSeverityError, tc.summary) == nil {
+				t.Errorf("got %v, want a %q error", summaries(diags), tc.summary)
+			}
+		})
+	}
+}
+
+func TestProviderVerifyOnConfigure(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config    map[string]any
+		wantCalls int
+		wantErr   bool
+	}{
+		"valid token":          {config: map[string]any{"verify_on_configure": true}, wantCalls: 1},
+		"bad token":            {config: map[string]any{"verify_on_configure": true, "token": "bad-token"}, wantCalls: 1, wantErr: true},
+		"bad token unverified": {config: map[string]any{"token": "bad-token"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := startTestProvider(t)
+			diags := p.configure(tc.config)
+			if failed := findDiag(diags, tfprotov6.DiagnosticSeverityError, "Could not reach the Voltage API") != nil; failed != tc.wantErr {
+				t.Errorf("got %v, want an error: %t", summaries(diags), tc.wantErr)
+			}
+			if got := p.api.Calls("/user"); got != tc.wantCalls {
+				t.Errorf("/user called %d times, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestProviderInsecureWarning(t *testing.T) {
+	for _, insecure := range []bool{false, true} {
+		diags := startTestProvider(t).configure(map[string]any{"insecure_skip_verify": insecure})
+		requireNoErrors(t, diags)
+		if warned := findDiag(diags, tfprotov6.DiagnosticSeverityWarning, "TLS verification is disabled") != nil; warned != insecure {
+			t.Errorf("insecure_skip_verify = %t: warned = %t", insecure, warned)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var statusDataSourceSchema = schema.Schema{
+	Description: "Checks that the Voltage API is reachable and the token is valid",
+	Attributes: map[string]schema.Attribute{
+		"reachable": schema.BoolAttribute{
+			Description: "Whether the API answered an authenticated request",
+			Computed:    true,
+		},
+		"user_id": schema.StringAttribute{
+			Description: "Unique ID of the user owning the token",
+			Computed:    true,
+		},
+		"email": schema.StringAttribute{
+			Description: "Email of the user owning the token",
+			Computed:    true,
+		},
+	},
+}
+
+type statusDataSourceModel struct {
+	Reachable types.Bool   `tfsdk:"reachable"`
+	UserID    types.String `tfsdk:"user_id"`
+	Email     types.String `tfsdk:"email"`
+}
+
+type StatusDataSource struct {
+	client *Client
+}
+
+func NewStatusDataSource() datasource.DataSource {
+	return &StatusDataSource{}
+}
+
+func (d *StatusDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_status"
+}
+
+func (d *StatusDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = statusDataSourceSchema
+}
+
+func (d *StatusDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '*provider.providerData', got: '%T'. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = data.client
+}
+
+func (d *StatusDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	user, err := d.client.GetUser(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(errToDiags(err)...)
+
+		return
+	}
+
+	state := statusDataSourceModel{
+		Reachable: types.BoolValue(true),
+		UserID:    types.StringPointerValue(user.UserId),
+		Email:     types.StringPointerValue(user.Email),
+	}
+
+	resp.Diagnostics.Append(
+		resp.State.Set(ctx, &state)...,
+	)
+}
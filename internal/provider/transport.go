@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// sensitiveFields are the JSON fields scrubbed from logged bodies.
+var sensitiveFields = map[string]bool{
+	"token":          true,
+	"webhook_secret": true,
+	"admin_macaroon": true,
+	"macaroon":       true,
+	"seed":           true,
+}
+
+// newHTTPClient builds the http.Client used to talk to the Voltage API.
+// If caCertFile is not empty, the PEM certificates it contains replace the
+// system root CAs, which is required when the API is reached through a
+// TLS-intercepting proxy or a self-signed staging host.
+// insecure disables the verification of the API host certificate, it is
+// only meant for local testing.
+// Requests are always logged at debug level, their bodies only when
+// logBodies is set.
+func newHTTPClient(caCertFile string, insecure, logBodies bool) (*http.Client, error) {
+	// The default transport dials through net.Dialer.DialContext, so the
+	// request context deadline also bounds connecting and the TLS handshake.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if caCertFile != "" {
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificate file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid PEM certificates found in %s", caCertFile)
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if insecure {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	return &http.Client{
+		Transport: &loggingTransport{next: transport, logBodies: logBodies},
+	}, nil
+}
+
+type loggingTransport struct {
+	next      http.RoundTripper
+	logBodies bool
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	fields := map[string]any{
+		"method": req.Method,
+		"path":   req.URL.Path,
+	}
+
+	if t.logBodies && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		fields["request_body"] = scrubBody(b)
+	}
+	tflog.Debug(ctx, "Sending API request", fields)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		tflog.Debug(ctx, "API request failed", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+
+	fields = map[string]any{
+		"method": req.Method,
+		"path":   req.URL.Path,
+		"status": resp.StatusCode,
+	}
+
+	if t.logBodies {
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		fields["response_body"] = scrubBody(b)
+	}
+	tflog.Debug(ctx, "Received API response", fields)
+
+	return resp, nil
+}
+
+// scrubBody returns body with the values of all the sensitiveFields
+// replaced. Bodies that aren't JSON are returned as they are.
+func scrubBody(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+
+	b, err := json.Marshal(scrub(v))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(b)
+}
+
+func scrub(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if sensitiveFields[k] {
+				v[k] = "***"
+			} else {
+				v[k] = scrub(val)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = scrub(val)
+		}
+	}
+
+	return v
+}
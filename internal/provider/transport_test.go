@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientConnectHonorsDeadline(t *testing.T) {
+	// A server that accepts connections but never completes the TLS
+	// handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	client, err := newHTTPClient("", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+l.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request succeeded, want it to time out")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %s to fail, want it bounded by its deadline", elapsed)
+	}
+}
+
+func TestHTTPClientInsecure(t *testing.T) {
+	// The certificate of the server isn't trusted by the system.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	for _, insecure := range []bool{false, true} {
+		client, err := newHTTPClient("", insecure, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if insecure && err != nil {
+			t.Errorf("insecure client: %v", err)
+		}
+		var certErr *tls.CertificateVerificationError
+		if !insecure && !errors.As(err, &certErr) {
+			t.Errorf("secure client: err = %v, want a certificate verification error", err)
+		}
+	}
+}
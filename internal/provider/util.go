@@ -1,9 +1,49 @@
 package provider
 
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
 func toPtr[T any](v T) *T {
 	return &v
 }
 
+// optionalString returns v as an optional API field: nil when v is null,
+// unknown or empty. Voltage takes an empty string as the value itself, e.g. an
+// empty alias, rather than as a request to use its default, so it's omitted.
+func optionalString(v types.String) *string {
+	if v.IsNull() || v.IsUnknown() || v.ValueString() == "" {
+		return nil
+	}
+
+	return toPtr(v.ValueString())
+}
+
+// stringSetting returns the API field p as an optional string setting, null
+// when p is nil or empty as Voltage reports unset settings as empty strings.
+func stringSetting(p *string) types.String {
+	if p == nil || *p == "" {
+		return types.StringNull()
+	}
+
+	return types.StringValue(*p)
+}
+
+func contains[T comparable](vs []T, v T) bool {
+	for _, x := range vs {
+		if x == v {
+			return true
+		}
+	}
+
+	return false
+}
+
 func each[T any, V any](ss []T, fn func(T) V) []V {
 	var vs = make([]V, len(ss))
 	for i := 0; i < len(ss); i++ {
@@ -12,3 +52,106 @@ func each[T any, V any](ss []T, fn func(T) V) []V {
 
 	return vs
 }
+
+// eachErr is like each, but fn may fail. It stops at the first error.
+func eachErr[T any, V any](ss []T, fn func(T) (V, error)) ([]V, error) {
+	var vs = make([]V, len(ss))
+	for i := 0; i < len(ss); i++ {
+		v, err := fn(ss[i])
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+
+	return vs, nil
+}
+
+// compareVersions compares two LND versions such as "v0.16.4-beta" ignoring
+// the pre-release suffix. It returns -1, 0 or +1 if a is lower, equal or
+// greater than b respectively.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		parts = append(parts, n)
+	}
+
+	return parts
+}
+
+// normalizeWhitelist returns ips in their canonical IP or CIDR form without
+// duplicates, keeping the order in which they first appear. Entries that
+// aren't valid IPs are kept as they are for the API to reject.
+func normalizeWhitelist(ips []string) []string {
+	seen := make(map[string]bool, len(ips))
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		ip = normalizeIP(ip)
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		out = append(out, ip)
+	}
+
+	return out
+}
+
+func normalizeIP(s string) string {
+	s = strings.TrimSpace(s)
+	addr, bits, isCIDR := strings.Cut(s, "/")
+
+	// net.ParseIP rejects zero-padded IPv4 octets, but they are a common
+	// way to write the same address, e.g. 192.168.000.001.
+	if !strings.Contains(addr, ":") {
+		octets := strings.Split(addr, ".")
+		for i, o := range octets {
+			if n, err := strconv.Atoi(o); err == nil && n >= 0 {
+				octets[i] = strconv.Itoa(n)
+			}
+		}
+		addr = strings.Join(octets, ".")
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return s
+	}
+	if !isCIDR {
+		return ip.String()
+	}
+
+	_, ipNet, err := net.ParseCIDR(ip.String() + "/" + bits)
+	if err != nil {
+		return s
+	}
+	ones, _ := ipNet.Mask.Size()
+
+	return fmt.Sprintf("%s/%d", ip, ones)
+}
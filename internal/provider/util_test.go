@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestNormalizeWhitelist(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ips, want []string
+	}{
+		"canonical": {
+			ips:  []string{"1.2.3.4", "10.0.0.0/8", "::1"},
+			want: []string{"1.2.3.4", "10.0.0.0/8", "::1"},
+		},
+		"zero padded": {
+			ips:  []string{"192.168.000.001", "010.000.000.000/08"},
+			want: []string{"192.168.0.1", "10.0.0.0/8"},
+		},
+		"ipv6": {
+			ips:  []string{"2001:DB8:0:0::1", "2001:db8::/032"},
+			want: []string{"2001:db8::1", "2001:db8::/32"},
+		},
+		"duplicates keep the first position": {
+			ips:  []string{"5.6.7.8", " 1.2.3.4", "5.6.7.008", "1.2.3.4"},
+			want: []string{"5.6.7.8", "1.2.3.4"},
+		},
+		"invalid entries are kept": {
+			ips:  []string{"not-an-ip", "1.2.3.4/33", "not-an-ip"},
+			want: []string{"not-an-ip", "1.2.3.4/33"},
+		},
+		"empty": {
+			ips:  []string{},
+			want: []string{},
+		},
+	} {
+		if got := normalizeWhitelist(tc.ips); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: normalizeWhitelist(%q) = %q, want %q", name, tc.ips, got, tc.want)
+		}
+	}
+}
+
+func TestOptionalString(t *testing.T) {
+	for name, tc := range map[string]struct {
+		v    types.String
+		want *string
+	}{
+		"null":      {v: types.StringNull()},
+		"unknown":   {v: types.StringUnknown()},
+		"empty":     {v: types.StringValue("")},
+		"non empty": {v: types.StringValue("value"), want: toPtr("value")},
+		"blank":     {v: types.StringValue(" "), want: toPtr(" ")},
+	} {
+		if got := optionalString(tc.v); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: optionalString(%s) = %v, want %v", name, tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestEachErr(t *testing.T) {
+	var calls int
+	atoi := func(s string) (int, error) {
+		calls++
+		return strconv.Atoi(s)
+	}
+
+	got, err := eachErr([]string{"1", "2", "3"}, atoi)
+	if err != nil || !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("eachErr = %v, %v, want [1 2 3]", got, err)
+	}
+
+	calls = 0
+	got, err = eachErr([]string{"1", "two", "three"}, atoi)
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || numErr.Num != "two" {
+		t.Errorf("err = %v, want the error of the first failed element", err)
+	}
+	if got != nil {
+		t.Errorf("got %v along with an error, want nil", got)
+	}
+	if calls != 2 {
+		t.Errorf("fn was called %d times, want it to stop at the first error", calls)
+	}
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// webhookURLValidator checks that a value is an absolute http(s) URL. Plain
+// http is accepted with a warning, as the webhook secret travels in clear.
+type webhookURLValidator struct{}
+
+func (v webhookURLValidator) Description(ctx context.Context) string {
+	return "value must be an absolute https URL"
+}
+
+func (v webhookURLValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v webhookURLValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid webhook URL",
+			fmt.Sprintf("Expected an absolute URL such as https://example.com/webhook, got: %q", value),
+		)
+
+		return
+	}
+
+	if u.Scheme == "http" {
+		resp.Diagnostics.AddAttributeWarning(
+			req.Path,
+			"Insecure webhook URL",
+			"Webhook events will be sent unencrypted, use an https URL instead.",
+		)
+	}
+}
+
+// durationValidator checks that a value is a positive duration such as "30m".
+type durationValidator struct{}
+
+func (v durationValidator) Description(ctx context.Context) string {
+	return "value must be a positive duration such as 30m or 1h30m"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid duration",
+			fmt.Sprintf("Expected a positive duration such as 30m or 1h30m, got: %q", value),
+		)
+	}
+}
+
+// intStringValidator checks that a string value is an integer between min and
+// max, for the settings Voltage takes as strings.
+type intStringValidator struct {
+	min, max int64
+}
+
+func (v intStringValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be an integer between %d and %d", v.min, v.max)
+}
+
+func (v intStringValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v intStringValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if n, err := strconv.ParseInt(value, 10, 64); err != nil || n < v.min || n > v.max {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid value",
+			fmt.Sprintf("Expected an integer between %d and %d, got: %q", v.min, v.max, value),
+		)
+	}
+}
+
+// jsonValuesValidator checks that all the values of a map of strings are valid
+// JSON documents.
+type jsonValuesValidator struct{}
+
+func (v jsonValuesValidator) Description(ctx context.Context) string {
+	return "values must be valid JSON, e.g. made with jsonencode"
+}
+
+func (v jsonValuesValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v jsonValuesValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for k, elem := range req.ConfigValue.Elements() {
+		s, ok := elem.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+
+		if !json.Valid([]byte(s.ValueString())) {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtMapKey(k),
+				"Invalid JSON value",
+				fmt.Sprintf("Expected a JSON value such as jsonencode(\"value\"), got: %q", s.ValueString()),
+			)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// validateString runs v against value and returns its diagnostics.
+func validateString(v validator.String, value types.String) diag.Diagnostics {
+	var resp validator.StringResponse
+	v.ValidateString(context.Background(), validator.StringRequest{
+		Path:        path.Root("test"),
+		ConfigValue: value,
+	}, &resp)
+
+	return resp.Diagnostics
+}
+
+func TestWebhookURLValidator(t *testing.T) {
+	for value, want := range map[string]struct {
+		errors, warnings int
+	}{
+		"https://example.com/webhook":      {},
+		"https://example.com:8443/webhook": {},
+		"http://example.com/webhook":       {warnings: 1},
+		"htps://example.com/webhook":       {errors: 1},
+		"example.com/webhook":              {errors: 1},
+		"https:///webhook":                 {errors: 1},
+		"https://exa mple.com":             {errors: 1},
+		"":                                 {errors: 1},
+	} {
+		diags := validateString(webhookURLValidator{}, types.StringValue(value))
+		if got := diags.ErrorsCount(); got != want.errors {
+			t.Errorf("%q: got %d errors, want %d: %v", value, got, want.errors, diags)
+		}
+		if got := diags.WarningsCount(); got != want.warnings {
+			t.Errorf("%q: got %d warnings, want %d: %v", value, got, want.warnings, diags)
+		}
+	}
+
+	for _, value := range []types.String{types.StringNull(), types.StringUnknown()} {
+		if diags := validateString(webhookURLValidator{}, value); len(diags) > 0 {
+			t.Errorf("%s: unexpected diagnostics %v", value, diags)
+		}
+	}
+}
+
+func TestIntStringValidator(t *testing.T) {
+	for name, tc := range map[string]struct {
+		v       intStringValidator
+		value   string
+		wantErr bool
+	}{
+		"valid fee rate":      {v: intStringValidator{max: maxFeeRate}, value: "2500"},
+		"zero fee rate":       {v: intStringValidator{max: maxFeeRate}, value: "0"},
+		"max fee rate":        {v: intStringValidator{max: maxFeeRate}, value: "100000"},
+		"negative fee rate":   {v: intStringValidator{max: maxFeeRate}, value: "-1", wantErr: true},
+		"over max fee rate":   {v: intStringValidator{max: maxFeeRate}, value: "100001", wantErr: true},
+		"valid base fee":      {v: intStringValidator{max: maxBaseFee}, value: "1000"},
+		"negative base fee":   {v: intStringValidator{max: maxBaseFee}, value: "-1000", wantErr: true},
+		"over max base fee":   {v: intStringValidator{max: maxBaseFee}, value: "10000001", wantErr: true},
+		"not an integer":      {v: intStringValidator{max: maxBaseFee}, value: "1.5", wantErr: true},
+		"not a number at all": {v: intStringValidator{max: maxBaseFee}, value: "cheap", wantErr: true},
+	} {
+		if got := validateString(tc.v, types.StringValue(tc.value)).HasError(); got != tc.wantErr {
+			t.Errorf("%s: %q rejected = %t, want %t", name, tc.value, got, tc.wantErr)
+		}
+	}
+}